@@ -7,6 +7,8 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 //
@@ -22,8 +24,13 @@ const (
 	FeedbackWarning
 	FeedbackPotentialProblem
 	FeedbackError
+	FeedbackRecoveredError // an error the parser recovered from (see ParseRecover)
 )
 
+func (fk FeedbackKind) isError() bool {
+	return fk == FeedbackError || fk == FeedbackRecoveredError
+}
+
 // FeedbackItem is just one item of feedback.
 type FeedbackItem struct {
 	Pos  int
@@ -40,7 +47,7 @@ func (fi *FeedbackItem) String() string {
 		msg = "WARNING: "
 	case FeedbackPotentialProblem:
 		msg = "PROBLEM?: "
-	case FeedbackError:
+	case FeedbackError, FeedbackRecoveredError:
 		msg = "ERROR: "
 	default:
 		msg = "UNKNOWN!!!: "
@@ -56,10 +63,14 @@ type ParseResult struct {
 	Value    interface{}
 	ErrPos   int
 	Feedback []*FeedbackItem
+	// HardError is true if the parser failed after a cut (see ParseCut), so
+	// no other alternative must be tried.
+	HardError bool
 }
 
 // HasError searches the feedback for errors and returns only true if it found
 // one.
+// Errors the parser recovered from don't count.
 func (pr *ParseResult) HasError() bool {
 	for _, fb := range pr.Feedback {
 		if fb.Kind == FeedbackError {
@@ -137,10 +148,31 @@ func (pd *ParseData) GetFeedback() (string, error) {
 	})
 	return feedbackInfo(pd.Result), feedbackError(pd.Result)
 }
+
+// GetFeedbackErrors works like GetFeedback but returns the parser errors as
+// multi-error (compatible with errors.Join).
+// So every single error can be found with errors.As.
+func (pd *ParseData) GetFeedbackErrors() (string, error) {
+	info, _ := pd.GetFeedback()
+	var errs []error
+	for _, fb := range pd.Result.Feedback {
+		if fb.Kind.isError() {
+			if pe, ok := fb.Msg.(*ParseError); ok {
+				errs = append(errs, pe)
+			} else if err, ok := fb.Msg.(error); ok {
+				errs = append(errs, err)
+			} else {
+				errs = append(errs, errors.New(fb.Msg.String()))
+			}
+		}
+	}
+	return info, errors.Join(errs...)
+}
+
 func feedbackError(pr *ParseResult) error {
 	b := strings.Builder{}
 	for _, fb := range pr.Feedback {
-		if fb.Kind == FeedbackError {
+		if fb.Kind.isError() {
 			if b.Len() > 0 {
 				b.WriteString("\n")
 			}
@@ -155,7 +187,7 @@ func feedbackError(pr *ParseResult) error {
 func feedbackInfo(pr *ParseResult) string {
 	b := strings.Builder{}
 	for _, fb := range pr.Feedback {
-		if fb.Kind != FeedbackError {
+		if !fb.Kind.isError() {
 			if b.Len() > 0 {
 				b.WriteString("\n")
 			}
@@ -179,16 +211,23 @@ func (pd *ParseData) ResetSourcePos(pos int) {
 // SourceData contains the name of the source for parsing, its contents and
 // unexported stuff.
 type SourceData struct {
-	Name        string
-	content     string
-	pos         int
-	wherePrevNl int
-	whereLine   int
+	Name      string
+	content   string // the whole content or the current window of a stream
+	pos       int
+	lines     []int // start offsets of all lines (in the window)
+	colMode   ColumnMode
+	tabWidth  int
+	set       *SourceSet    // source set the source belongs to (if any)
+	base      int           // global position of the start of the source
+	stream    *sourceStream // reader of a streamed source (if any)
+	offset    int           // position of the start of the window
+	firstLine int           // number of lines before the window
+	reach     int           // position after the last byte examined by a parser
 }
 
 // NewSourceData creates a new, completely initialized SourceData.
 func NewSourceData(name string, content string) SourceData {
-	return SourceData{name, content, 0, -1, 1}
+	return SourceData{Name: name, content: content, lines: newLineIndex(content)}
 }
 
 // Where describes the given integer position in a human-readable way.
@@ -196,11 +235,113 @@ func (sd SourceData) Where(pos int) string {
 	return where(&sd, pos)
 }
 
+// LineCol returns the line and column (both starting at 1) of the given
+// byte offset.
+// The column is counted according to the column mode (see SetColumnMode).
+// Offsets outside of the content are moved to its start or end.
+func (sd SourceData) LineCol(offset int) (line, col int) {
+	line, col, _ = wherePos(&sd, offset)
+	return line, col
+}
+
+// Offset returns the byte offset of the given line and column (both starting
+// at 1).
+// The column is counted according to the column mode (see SetColumnMode).
+// The column just after the end of a line is valid, too.
+// If the line or column doesn't exist, an error is returned.
+func (sd SourceData) Offset(line, col int) (int, error) {
+	lines := sd.lineIndex()
+	if line <= sd.firstLine || line > sd.firstLine+len(lines) {
+		return 0, fmt.Errorf("line %d is out of range [%d, %d]", line, sd.firstLine+1, sd.firstLine+len(lines))
+	}
+	start, end := lineBounds(&sd, lines, line-1-sd.firstLine)
+	srcLine := sd.slice(start, end)
+	n, ok := sd.columnOffset(srcLine, col)
+	if !ok {
+		return 0, fmt.Errorf("column %d is out of range [1, %d] in line %d",
+			col, sd.column(srcLine, end-start), line)
+	}
+	return start + n, nil
+}
+
+// lineIndex returns the index of line starts.
+// It is computed on the fly if the SourceData wasn't created with
+// NewSourceData.
+func (sd *SourceData) lineIndex() []int {
+	if sd.lines == nil {
+		return newLineIndex(sd.content)
+	}
+	return sd.lines
+}
+
+// resolve returns the source containing the position and the position in
+// that source.
+// Positions after the end of a source of a SourceSet are global positions
+// (relative to its base) of included sources (see IncludeSource).
+func (sd *SourceData) resolve(pos int) (*SourceData, int) {
+	if sd.set != nil && (pos < 0 || pos > len(sd.content)) {
+		if src, p := sd.set.Source(sd.base + pos); src != nil {
+			return src, p
+		}
+	}
+	return sd, pos
+}
+
+// end returns the position of the end of the content (or window).
+func (sd *SourceData) end() int {
+	return sd.offset + len(sd.content)
+}
+
+// slice returns the content between the given positions.
+// Released content of streamed sources is left out.
+func (sd *SourceData) slice(start, end int) string {
+	return sd.content[max(start, sd.offset)-sd.offset : end-sd.offset]
+}
+
+// touch records that parsers examined the content until the given position
+// (exclusive).
+func (sd *SourceData) touch(end int) {
+	sd.reach = max(sd.reach, end)
+}
+
+// rest returns the content from the current position on.
+// For streamed sources at least the configured window is read ahead
+// (if possible).
+func (sd *SourceData) rest() string {
+	return sd.from(sd.pos, 0)
+}
+
+// restN returns the content from the current position on.
+// For streamed sources at least n bytes are read ahead (if possible).
+func (sd *SourceData) restN(n int) string {
+	return sd.from(sd.pos, n)
+}
+
+// from returns the content from the given position on.
+// For streamed sources at least n bytes (or the window if n isn't positive)
+// are read ahead (if possible).
+func (sd *SourceData) from(pos, n int) string {
+	if sd.stream != nil {
+		sd.stream.lookahead(sd, pos, n)
+	}
+	pos = max(sd.offset, min(pos, sd.end()))
+	return sd.content[pos-sd.offset:]
+}
+
 // ParseData contains all data needed during parsing.
 type ParseData struct {
 	Source     SourceData
 	Result     *ParseResult
 	SubResults []*ParseResult
+	Memo       *MemoTable // optional cache for memoized subparsers
+	Tracer     Tracer     // optional tracer for debugging and profiling
+	seeds      *MemoTable // seeds of ParseLeftRec if Memo isn't set
+	ruleName   string     // name of the current grammar rule
+	failure    *failure   // furthest failure of the whole parse
+	predicates int        // depth of nested predicates (ParseNot, ParseAnd)
+	backtracks int        // depth of nested parsers that might backtrack
+	cut        bool       // true if the current ParseAll has passed a cut
+	describer  *describer // set if parsers describe themselves instead of parsing
 }
 
 // NewParseData creates a new, completely initialized ParseData.
@@ -209,49 +350,211 @@ func NewParseData(name string, content string) *ParseData {
 }
 
 // parseMessage holds some information from the parser.
+// The position is rendered lazily because most messages are never shown.
 type parseMessage struct {
+	src   *SourceData // source for rendering the position (if rendered lazily)
+	pos   int
 	where string
+	rule  string
 	msg   string
 }
 
 // newParseMessage creates a new, completely initialized parseMessage.
 func newParseMessage(pd *ParseData, pos int, msg string) *parseMessage {
-	return &parseMessage{where: pd.Source.Where(pos), msg: msg}
+	if pd.Source.stream != nil {
+		return &parseMessage{where: where(&pd.Source, pos), rule: pd.ruleName, msg: msg}
+	}
+	return &parseMessage{src: &pd.Source, pos: pos, rule: pd.ruleName, msg: msg}
 }
 func (i *parseMessage) String() string {
 	b := &strings.Builder{}
-	b.WriteString(i.where)
+	if i.src != nil {
+		b.WriteString(where(i.src, i.pos))
+	} else {
+		b.WriteString(i.where)
+	}
+	b.WriteString(rulePrefix(i.rule))
 	b.WriteString(i.msg)
 	b.WriteRune('.')
 
 	return b.String()
 }
 
-// parseError holds information about a parser error.
-type parseError struct {
-	where   string
-	myErr   string
-	baseErr error
-}
-
-// newParseError creates a new, completely initialized parseError.
-func newParseError(pd *ParseData, pos int, msg string, baseErr error) *parseError {
-	return &parseError{where: pd.Source.Where(pos), myErr: msg, baseErr: baseErr}
+// ParseError holds all information about a parser error.
+// Line, Column and SourceLine are computed lazily because most errors are
+// discarded during backtracking.
+type ParseError struct {
+	Source   string   // name of the source
+	Offset   int      // byte offset in the source
+	Rule     string   // name of the current grammar rule
+	Msg      string   // the message of the error
+	Expected []string // alternatives that would have been valid
+	Err      error    // the wrapped base error
+
+	src *SourceData    // source for computing the position (if any)
+	pos *errorPosition // shared by all copies of the error
+}
+
+// errorPosition is the position of a ParseError.
+// It is computed only once, even if that happens concurrently.
+type errorPosition struct {
+	once       sync.Once
+	line       int
+	column     int
+	sourceLine string
+}
+
+// allocParseError allocates the error together with its (empty) position,
+// so errors are created with a single allocation.
+func allocParseError(pe ParseError) *ParseError {
+	e := &struct {
+		pe  ParseError
+		pos errorPosition
+	}{pe: pe}
+	e.pe.pos = &e.pos
+	return &e.pe
+}
+
+// newParseError creates a new ParseError without computing the position.
+// The position of streamed sources is computed right away because the
+// content might be released later.
+func newParseError(pd *ParseData, pos int, msg string, baseErr error) *ParseError {
+	pe := allocParseError(ParseError{
+		Source: pd.Source.Name,
+		Offset: pos,
+		Rule:   pd.ruleName,
+		Msg:    msg,
+		Err:    baseErr,
+		src:    &pd.Source,
+	})
+	if pd.Source.stream != nil {
+		pe.position()
+	}
+	return pe
 }
 
-func (e *parseError) Error() string {
-	msg := e.where + e.myErr
-	if e.baseErr != nil {
-		msg += ":\n" + e.baseErr.Error()
+// Error returns the complete message of the error including position, source
+// line and base error.
+func (e *ParseError) Error() string {
+	msg := e.where() + rulePrefix(e.Rule) + e.Msg
+	if e.Err != nil {
+		msg += ":\n" + e.Err.Error()
 	} else {
 		msg += "."
 	}
 	return msg
 }
-func (e *parseError) String() string {
+func (e *ParseError) String() string {
 	return e.Error()
 }
 
+// Unwrap returns the base error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Line returns the line number starting at 1 (0 if the position is
+// unknown).
+func (e *ParseError) Line() int {
+	return e.position().line
+}
+
+// Column returns the column number starting at 1.
+func (e *ParseError) Column() int {
+	return e.position().column
+}
+
+// SourceLine returns the offending line of the source.
+func (e *ParseError) SourceLine() string {
+	return e.position().sourceLine
+}
+
+// position computes line, column and source line if that hasn't happened
+// yet.
+func (e *ParseError) position() *errorPosition {
+	if e.src == nil || e.pos == nil {
+		return &errorPosition{}
+	}
+	e.pos.once.Do(func() {
+		e.pos.line, e.pos.column, e.pos.sourceLine = wherePos(e.src, e.Offset)
+	})
+	return e.pos
+}
+func (e *ParseError) where() string {
+	p := e.position()
+	if p.line == 0 {
+		return ""
+	}
+	return generateWhereMessage(e.Source, p.line, p.column, p.sourceLine)
+}
+
+// failure is the furthest position where a parser failed together with
+// everything that was expected there.
+type failure struct {
+	pos      int
+	expected []string
+	src      *SourceData // included source of the failure (nil for the parsed source)
+	srcPos   int         // position of the failure in the included source
+}
+
+// FurthestError returns an error for the furthest position any parser
+// failed at, e.g.:
+// "expected one of 'if', 'for', identifier; found '}'".
+// This is usually more helpful than all the errors of the feedback.
+// If no parser failed, nil is returned.
+func (pd *ParseData) FurthestError() *ParseError {
+	if pd.failure == nil {
+		return nil
+	}
+	src, pos := &pd.Source, pd.failure.pos
+	if pd.failure.src != nil {
+		src, pos = pd.failure.src, pd.failure.srcPos
+	}
+	msg := "expected "
+	if len(pd.failure.expected) > 1 {
+		msg += "one of "
+	}
+	msg += strings.Join(pd.failure.expected, ", ") + "; found "
+	if rest := src.from(pos, utf8.UTFMax); rest == "" {
+		msg += "end of input"
+	} else {
+		r, _ := utf8.DecodeRuneInString(rest)
+		msg += strconv.QuoteRune(r)
+	}
+	pe := newParseError(pd, pos, msg, nil)
+	pe.Source, pe.src, pe.pos = src.Name, src, &errorPosition{}
+	pe.Expected = slices.Clone(pd.failure.expected)
+	pe.position() // the source might be released later
+	return pe
+}
+
+// expect records that something was expected at the given position but
+// wasn't found.
+// Only the furthest position is kept and expectations inside of predicates
+// are ignored.
+func expect(pd *ParseData, pos int, expected string) {
+	if pd.predicates > 0 {
+		return
+	}
+	switch {
+	case pd.failure == nil:
+		pd.failure = &failure{pos: pos, expected: []string{expected}}
+	case pos > pd.failure.pos:
+		pd.failure.pos = pos
+		pd.failure.expected = append(pd.failure.expected[:0], expected)
+		pd.failure.src = nil
+	case pos == pd.failure.pos && pd.failure.src == nil && !slices.Contains(pd.failure.expected, expected):
+		pd.failure.expected = append(pd.failure.expected, expected)
+	}
+}
+
+func rulePrefix(rule string) string {
+	if rule == "" {
+		return ""
+	}
+	return "In rule '" + rule + "': "
+}
+
 // ------- Base for all parsers:
 
 // SubparserOp is a simple filter to the outside and gets the same data as the
@@ -278,7 +581,7 @@ func createMatchedResult(pd *ParseData, n int) {
 	n += i
 	pd.Result = &ParseResult{
 		Pos:      i,
-		Text:     pd.Source.content[i:n],
+		Text:     pd.Source.slice(i, n),
 		Value:    nil,
 		ErrPos:   -1,
 		Feedback: make([]*FeedbackItem, 0, 8),
@@ -287,68 +590,80 @@ func createMatchedResult(pd *ParseData, n int) {
 }
 func createUnmatchedResult(pd *ParseData, i int, msg string, baseErr error) {
 	i += pd.Source.pos
-	pd.Result = &ParseResult{pd.Source.pos, "", nil, i, make([]*FeedbackItem, 0, 64)}
+	pd.Result = &ParseResult{pd.Source.pos, "", nil, i, make([]*FeedbackItem, 0, 1), false}
 	pd.AddError(i, msg, baseErr)
 }
 
-func where(src *SourceData, pos int) string {
-	if src.content == "" {
-		return generateWhereMessage(src.Name, 1, 1, "")
-	}
-	if pos >= src.wherePrevNl {
-		return whereForward(src, pos)
-	} else if pos <= src.wherePrevNl-pos {
-		src.whereLine = 1
-		src.wherePrevNl = -1
-		return whereForward(src, pos)
-	} else {
-		return whereBackward(src, pos)
+// createExpectedResult works like createUnmatchedResult but the error knows
+// what was expected (ParseError.Expected) and the expectation is recorded
+// for FurthestError, too.
+func createExpectedResult(pd *ParseData, i int, expected string, msg string, baseErr error) {
+	expect(pd, pd.Source.pos+i, expected)
+	createUnmatchedResult(pd, i, msg, baseErr)
+	pd.Result.Feedback[0].Msg.(*ParseError).Expected = []string{expected}
+}
+
+// expectAlternatives adds everything the errors of the feedback at the
+// position of the error of the current result expected to that error
+// (ParseError.Expected).
+func expectAlternatives(pd *ParseData, feedback []*FeedbackItem) {
+	pe := pd.Result.Feedback[0].Msg.(*ParseError)
+	for _, fb := range feedback {
+		if sub, ok := fb.Msg.(*ParseError); ok && fb.Pos == pe.Offset {
+			for _, e := range sub.Expected {
+				if !slices.Contains(pe.Expected, e) {
+					pe.Expected = append(pe.Expected, e)
+				}
+			}
+		}
 	}
 }
-func whereForward(src *SourceData, pos int) string {
-	text := src.content
-	lineNum := src.whereLine  // Line number
-	prevNl := src.wherePrevNl // Line start (position of preceding newline)
-	var nextNl int            // Position of next newline or end
 
-	for {
-		nextNl = strings.IndexByte(text[prevNl+1:], '\n')
-		if nextNl < 0 {
-			nextNl = len(text)
-		} else {
-			nextNl += prevNl + 1
-		}
-		where, stop := tryWhere(src, prevNl, pos, nextNl, lineNum)
-		if stop {
-			return where
-		}
-		prevNl = nextNl
-		lineNum++
-	}
-}
-func whereBackward(src *SourceData, pos int) string {
-	text := src.content
-	lineNum := src.whereLine  // Line number
-	var prevNl int            // Line start (position of preceding newline)
-	nextNl := src.wherePrevNl // Position of next newline or end
-
-	for {
-		prevNl = strings.LastIndexByte(text[0:nextNl], '\n')
-		lineNum--
-		where, stop := tryWhere(src, prevNl, pos, nextNl, lineNum)
-		if stop {
-			return where
+func where(src *SourceData, pos int) string {
+	src, pos = src.resolve(pos)
+	line, col, srcLine := wherePos(src, pos)
+	return generateWhereMessage(src.Name, line, col, srcLine)
+}
+func wherePos(src *SourceData, pos int) (line, col int, srcLine string) {
+	src, pos = src.resolve(pos)
+	pos = max(src.offset, min(pos, src.end()))
+	lines := src.lineIndex()
+	i, _ := lineOf(lines, pos)
+	start, end := lineBounds(src, lines, i)
+	srcLine = src.slice(start, end)
+	return src.firstLine + i + 1, src.column(srcLine, pos-start), srcLine
+}
+func newLineIndex(content string) []int {
+	lines := make([]int, 1, strings.Count(content, "\n")+1)
+	for i := 0; ; {
+		j := strings.IndexByte(content[i:], '\n')
+		if j < 0 {
+			return lines
 		}
-		nextNl = prevNl
+		i += j + 1
+		lines = append(lines, i)
 	}
 }
-func tryWhere(src *SourceData, prevNl int, pos int, nextNl int, lineNum int) (where string, stop bool) {
-	if prevNl < pos && pos <= nextNl {
-		src.wherePrevNl = prevNl
-		src.whereLine = lineNum
-		return generateWhereMessage(src.Name, lineNum, pos-prevNl, src.content[prevNl+1:nextNl]), true
+
+// lineOf returns the index of the line containing the offset and the start
+// of the line.
+func lineOf(lines []int, offset int) (i, start int) {
+	i, found := slices.BinarySearch(lines, offset)
+	if !found {
+		i--
+	}
+	return i, lines[i]
+}
+
+// lineBounds returns the start and end (position of the newline or end of
+// content) of the line with the given index.
+func lineBounds(sd *SourceData, lines []int, i int) (start, end int) {
+	start = lines[i]
+	end = sd.end()
+	if i+1 < len(lines) {
+		end = lines[i+1] - 1
 	}
-	return "", false
+	return start, end
 }
 func generateWhereMessage(name string, line int, col int, srcLine string) string {
 	return "File '" + name + "', line " + strconv.Itoa(line) +
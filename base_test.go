@@ -2,18 +2,14 @@ package gparselib
 
 import (
 	"errors"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 )
 
 func TestWhere(t *testing.T) {
-	src := &SourceData{
-		Name:        "file1",
-		content:     "content\nline2\nline3\nand4\n",
-		pos:         15,
-		wherePrevNl: 13,
-		whereLine:   3,
-	}
+	src := sourceData("file1", "content\nline2\nline3\nand4\n", 15)
 
 	specs := []struct {
 		givenSourceData *SourceData
@@ -57,14 +53,8 @@ func TestWhere(t *testing.T) {
 				"\ncontent\n",
 			},
 		}, {
-			givenSourceData: &SourceData{
-				Name:        "empty",
-				content:     "",
-				pos:         0,
-				wherePrevNl: -1,
-				whereLine:   1,
-			},
-			givenPosition: 0,
+			givenSourceData: sourceData("empty", "", 0),
+			givenPosition:   0,
 			expectedStrings: []string{
 				"File 'empty'",
 				"line 1",
@@ -89,6 +79,46 @@ func TestWhere(t *testing.T) {
 	}
 }
 
+func sourceData(name, content string, pos int) *SourceData {
+	sd := NewSourceData(name, content)
+	sd.pos = pos
+	return &sd
+}
+
+func TestSourceData_LineCol(t *testing.T) {
+	sd := NewSourceData("file1", "content\nline2\n\nand4")
+	specs := []struct {
+		offset, line, col int
+	}{
+		{offset: 0, line: 1, col: 1},
+		{offset: 7, line: 1, col: 8},
+		{offset: 8, line: 2, col: 1},
+		{offset: 13, line: 2, col: 6},
+		{offset: 14, line: 3, col: 1},
+		{offset: 15, line: 4, col: 1},
+		{offset: 19, line: 4, col: 5},
+	}
+	for _, spec := range specs {
+		line, col := sd.LineCol(spec.offset)
+		if line != spec.line || col != spec.col {
+			t.Errorf("offset %d: expected line %d, column %d, got: line %d, column %d",
+				spec.offset, spec.line, spec.col, line, col)
+		}
+		offset, err := sd.Offset(spec.line, spec.col)
+		if err != nil {
+			t.Errorf("line %d, column %d: unexpected error: %v", spec.line, spec.col, err)
+		} else if offset != spec.offset {
+			t.Errorf("line %d, column %d: expected offset %d, got: %d", spec.line, spec.col, spec.offset, offset)
+		}
+	}
+
+	for _, lc := range [][2]int{{0, 1}, {5, 1}, {1, 0}, {1, 9}, {3, 2}, {4, 6}} {
+		if offset, err := sd.Offset(lc[0], lc[1]); err == nil {
+			t.Errorf("line %d, column %d: expected an error, got offset: %d", lc[0], lc[1], offset)
+		}
+	}
+}
+
 func TestCreateUnmatchedResult(t *testing.T) {
 	specs := []struct {
 		givenParseData       *ParseData
@@ -99,13 +129,7 @@ func TestCreateUnmatchedResult(t *testing.T) {
 	}{
 		{
 			givenParseData: &ParseData{
-				Source: SourceData{
-					Name:        "file1",
-					content:     "content\nline2\nline3\nand4\n",
-					pos:         15,
-					wherePrevNl: 13,
-					whereLine:   3,
-				},
+				Source: *sourceData("file1", "content\nline2\nline3\nand4\n", 15),
 			},
 			givenErrOffset:       0,
 			givenErrMsg:          "Bust1",
@@ -113,13 +137,7 @@ func TestCreateUnmatchedResult(t *testing.T) {
 			expectedResultErrPos: 15,
 		}, {
 			givenParseData: &ParseData{
-				Source: SourceData{
-					Name:        "file1",
-					content:     "content\nline2\nline3\nand4\n",
-					pos:         15,
-					wherePrevNl: 13,
-					whereLine:   3,
-				},
+				Source: *sourceData("file1", "content\nline2\nline3\nand4\n", 15),
 			},
 			givenErrOffset:       3,
 			givenErrMsg:          "Bust2",
@@ -191,13 +209,7 @@ func TestCreateMatchedResult(t *testing.T) {
 	}{
 		{
 			givenParseData: &ParseData{
-				Source: SourceData{
-					Name:        "file1",
-					content:     "content\nline2\nline3\nand4\n",
-					pos:         15,
-					wherePrevNl: 13,
-					whereLine:   3,
-				},
+				Source: *sourceData("file1", "content\nline2\nline3\nand4\n", 15),
 			},
 			givenN:               0,
 			expectedResultPos:    15,
@@ -205,13 +217,7 @@ func TestCreateMatchedResult(t *testing.T) {
 			expectedResultText:   "",
 		}, {
 			givenParseData: &ParseData{
-				Source: SourceData{
-					Name:        "file1",
-					content:     "content\nline2\nline3\nand4\n",
-					pos:         15,
-					wherePrevNl: 13,
-					whereLine:   3,
-				},
+				Source: *sourceData("file1", "content\nline2\nline3\nand4\n", 15),
 			},
 			givenN:               4,
 			expectedResultPos:    15,
@@ -318,13 +324,13 @@ func TestGetFeedback(t *testing.T) {
 					Feedback: []*FeedbackItem{
 						{
 							Kind: FeedbackError,
-							Msg:  &parseError{myErr: "err 1"},
+							Msg:  &ParseError{Msg: "err 1"},
 						},
 					},
 				},
 			},
 			expectedInfo:  "",
-			expectedError: &parseError{myErr: "ERROR: err 1"},
+			expectedError: &ParseError{Msg: "ERROR: err 1"},
 		}, {
 			name: "all feedback",
 			givenParseData: &ParseData{
@@ -332,7 +338,7 @@ func TestGetFeedback(t *testing.T) {
 					Feedback: []*FeedbackItem{
 						{
 							Kind: FeedbackError,
-							Msg:  &parseError{myErr: "err 2"},
+							Msg:  &ParseError{Msg: "err 2"},
 						}, {
 							Pos:  1,
 							Kind: FeedbackWarning,
@@ -346,7 +352,7 @@ func TestGetFeedback(t *testing.T) {
 				},
 			},
 			expectedInfo:  "WARNING: warn 2.\nINFO: info 2.",
-			expectedError: &parseError{myErr: "ERROR: err 2"},
+			expectedError: &ParseError{Msg: "ERROR: err 2"},
 		}, {
 			name: "multi error feedback",
 			givenParseData: &ParseData{
@@ -354,10 +360,10 @@ func TestGetFeedback(t *testing.T) {
 					Feedback: []*FeedbackItem{
 						{
 							Kind: FeedbackError,
-							Msg:  &parseError{myErr: "err 3"},
+							Msg:  &ParseError{Msg: "err 3"},
 						}, {
 							Kind: FeedbackError,
-							Msg:  &parseError{myErr: "err 4"},
+							Msg:  &ParseError{Msg: "err 4"},
 						},
 					},
 				},
@@ -382,3 +388,116 @@ func TestGetFeedback(t *testing.T) {
 		})
 	}
 }
+
+func TestParseError(t *testing.T) {
+	baseErr := errors.New("base error")
+	pd := NewParseData("file1", "content\nline2\nline3\nand4\n")
+	pd.Source.pos = 15
+	pd.ruleName = "r"
+	createUnmatchedResult(pd, 2, "Bust", baseErr)
+
+	info, err := pd.GetFeedbackErrors()
+	if info != "" {
+		t.Errorf("expected no info feedback, got: %q", info)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a *ParseError, got: %#v", err)
+	}
+	if pe.Source != "file1" || pe.Offset != 17 || pe.Line() != 3 || pe.Column() != 4 ||
+		pe.SourceLine() != "line3" || pe.Rule != "r" || pe.Msg != "Bust" {
+		t.Errorf("unexpected parse error: %#v", pe)
+	}
+	if !errors.Is(err, baseErr) {
+		t.Errorf("expected the base error to be wrapped, got: %v", err)
+	}
+	expectedMsg := "File 'file1', line 3, column 4:\nline3\nIn rule 'r': Bust:\nbase error"
+	if pe.Error() != expectedMsg {
+		t.Errorf("expected error message %q, got: %q", expectedMsg, pe.Error())
+	}
+
+	createUnmatchedResult(pd, 0, "Bust again", nil)
+	pd.Result.Feedback = append(pd.Result.Feedback, &FeedbackItem{Pos: pe.Offset, Kind: FeedbackError, Msg: pe})
+	_, err = pd.GetFeedbackErrors()
+	if errs, ok := err.(interface{ Unwrap() []error }); !ok || len(errs.Unwrap()) != 2 {
+		t.Errorf("expected a multi-error with 2 errors, got: %#v", err)
+	}
+}
+
+func TestParseError_Lazy(t *testing.T) {
+	pd := NewParseData("file1", "content\nline2\nline3\nand4\n")
+	pd.Source.pos = 15
+	createUnmatchedResult(pd, 0, "Bust", nil)
+
+	pe := pd.Result.Feedback[0].Msg.(*ParseError)
+	if pe.pos.line != 0 {
+		t.Errorf("expected the position to be computed lazily, got line: %d", pe.pos.line)
+	}
+	cp := *pe // copies share the position
+	if cp.Line() != 3 || pe.Column() != 2 || pe.SourceLine() != "line3" {
+		t.Errorf("expected line 3, column 2 and source line 'line3', got: %d, %d, %q",
+			pe.Line(), pe.Column(), pe.SourceLine())
+	}
+	if pe.pos.line != 3 {
+		t.Errorf("expected the position to be shared by all copies, got line: %d", pe.pos.line)
+	}
+	expectedMsg := "File 'file1', line 3, column 2:\nline3\nBust."
+	if pe.Error() != expectedMsg {
+		t.Errorf("expected error message %q, got: %q", expectedMsg, pe.Error())
+	}
+}
+
+func TestParseError_Expected(t *testing.T) {
+	pRegexp, err := NewParseRegexpPlugin(nil, "[a-z]+")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pFunc := NewParseLiteralPlugin(nil, "func")
+	pIdent := NewParseIdentPlugin(nil, "", "")
+	pAny := NewParseAnyPlugin([]SubparserOp{pFunc, NewParseAllPlugin([]SubparserOp{pIdent, pFunc}, nil)}, nil)
+	specs := []struct {
+		name     string
+		parser   SubparserOp
+		expected []string
+	}{
+		{name: "literal", parser: pFunc, expected: []string{"'func'"}},
+		{name: "ident", parser: pIdent, expected: []string{"identifier"}},
+		{name: "regexp", parser: pRegexp, expected: []string{"`[a-z]+`"}},
+		{name: "any", parser: pAny, expected: []string{"'func'", "identifier"}},
+		{name: "best", parser: NewParseBestPlugin([]SubparserOp{pIdent, pRegexp}, nil), expected: []string{"identifier", "`[a-z]+`"}},
+		{name: "multi", parser: NewParseMulti1Plugin(pAny, nil), expected: []string{"'func'", "identifier"}},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(tt *testing.T) {
+			pd, _ := spec.parser(NewParseData(spec.name, "123"), nil)
+			_, err := pd.GetFeedbackErrors()
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				tt.Fatalf("expected a *ParseError, got: %#v", err)
+			}
+			if !slices.Equal(pe.Expected, spec.expected) {
+				tt.Errorf("expected %q to be expected, got: %q", spec.expected, pe.Expected)
+			}
+		})
+	}
+}
+
+func TestParseError_Concurrent(t *testing.T) {
+	pd := NewParseData("file1", "content\nline2\nline3\nand4\n")
+	pd.Source.pos = 15
+	createUnmatchedResult(pd, 0, "Bust", nil)
+
+	pe := pd.Result.Feedback[0].Msg.(*ParseError)
+	expectedMsg := "File 'file1', line 3, column 2:\nline3\nBust."
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if msg := pe.Error(); msg != expectedMsg {
+				t.Errorf("expected error message %q, got: %q", expectedMsg, msg)
+			}
+		}()
+	}
+	wg.Wait()
+}
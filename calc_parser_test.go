@@ -0,0 +1,294 @@
+// Code generated by gparsegen from calc.peg. DO NOT EDIT.
+
+package gparselib_test
+
+import (
+	"errors"
+
+	"github.com/flowdev/gparselib"
+)
+
+// calcParser is a parser for the grammar in 'calc.peg'.
+type calcParser struct {
+	calcBodySubs       []gparselib.SubparserOp
+	exprSeq1Subs       []gparselib.SubparserOp
+	exprSeq3Subs       []gparselib.SubparserOp
+	exprBodySubs       []gparselib.SubparserOp
+	termSeq1Subs       []gparselib.SubparserOp
+	termBodySubs       []gparselib.SubparserOp
+	numberBodySubs     []gparselib.SubparserOp
+	keywordChoice1Subs []gparselib.SubparserOp
+	keywordBodySubs    []gparselib.SubparserOp
+	anyBodySubs        []gparselib.SubparserOp
+	actions            [5]gparselib.SemanticsOp
+	regexps            [4]*gparselib.RegexpParser
+}
+
+// newCalcParser creates a new parser for the grammar in 'calc.peg'.
+// The semantic actions used in the grammar are looked up in actions.
+func newCalcParser(actions map[string]gparselib.SemanticsOp) (*calcParser, error) {
+	p := &calcParser{}
+	p.calcBodySubs = []gparselib.SubparserOp{p.parseSpace, p.parseExpr, p.calcNot1}
+	p.exprSeq1Subs = []gparselib.SubparserOp{p.parseExpr, p.exprLiteral2, p.parseSpace, p.parseTerm}
+	p.exprSeq3Subs = []gparselib.SubparserOp{p.parseExpr, p.exprLiteral4, p.parseSpace, p.parseTerm}
+	p.exprBodySubs = []gparselib.SubparserOp{p.exprSeq1, p.exprSeq3, p.parseTerm}
+	p.termSeq1Subs = []gparselib.SubparserOp{p.termLiteral2, p.parseSpace, p.parseExpr, p.termLiteral3, p.parseSpace}
+	p.termBodySubs = []gparselib.SubparserOp{p.parseNumber, p.termSeq1}
+	p.numberBodySubs = []gparselib.SubparserOp{p.numberOneOrMore1, p.parseSpace}
+	p.keywordChoice1Subs = []gparselib.SubparserOp{p.keywordLiteral2, p.keywordLiteral3}
+	p.keywordBodySubs = []gparselib.SubparserOp{p.keywordChoice1, p.keywordNot4}
+	p.anyBodySubs = []gparselib.SubparserOp{p.anyAnd1, p.anyAnyChar3, p.anyOptional4}
+	for i, name := range [5]string{"add", "sub", "paren", "number", "text"} {
+		if p.actions[i] = actions[name]; p.actions[i] == nil {
+			return nil, errors.New("semantic action '" + name + "' isn't defined")
+		}
+	}
+	for i, re := range [4]string{"(?s).", "[0-9]", "[ \\t\\n]", "[a-z]"} {
+		var err error
+		if p.regexps[i], err = gparselib.NewRegexpParser(re); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Parse parses the input with the start rule 'calc'.
+func (p *calcParser) Parse(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.parseCalc(pd, ctx)
+}
+
+// Rule returns the parser for the named rule or nil if there is no such rule.
+func (p *calcParser) Rule(name string) gparselib.SubparserOp {
+	switch name {
+	case "calc":
+		return p.parseCalc
+	case "expr":
+		return p.parseExpr
+	case "term":
+		return p.parseTerm
+	case "number":
+		return p.parseNumber
+	case "space":
+		return p.parseSpace
+	case "keyword":
+		return p.parseKeyword
+	case "any":
+		return p.parseAny
+	}
+	return nil
+}
+
+// calc <- space expr !.
+func (p *calcParser) parseCalc(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.calcMemo, "calc")
+}
+
+// memoized rule calc
+func (p *calcParser) calcMemo(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMemo(pd, ctx, p.calcBody, "calc")
+}
+
+// space expr !.
+func (p *calcParser) calcBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.calcBodySubs, nil)
+}
+
+// !.
+func (p *calcParser) calcNot1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseNot(pd, ctx, p.calcAnyChar2, nil)
+}
+
+// .
+func (p *calcParser) calcAnyChar2(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.regexps[0].ParseRegexp(pd, ctx, nil)
+}
+
+// expr <- expr '+' space term {add} / expr '-' space term {sub} / term
+func (p *calcParser) parseExpr(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.exprLeftRec, "expr")
+}
+
+// left recursive rule expr
+func (p *calcParser) exprLeftRec(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLeftRec(pd, ctx, p.exprBody, "expr")
+}
+
+// expr '+' space term {add} / expr '-' space term {sub} / term
+func (p *calcParser) exprBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAny(pd, ctx, p.exprBodySubs, nil)
+}
+
+// expr '+' space term {add}
+func (p *calcParser) exprSeq1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.exprSeq1Subs, p.actions[0])
+}
+
+// '+'
+func (p *calcParser) exprLiteral2(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, "+")
+}
+
+// expr '-' space term {sub}
+func (p *calcParser) exprSeq3(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.exprSeq3Subs, p.actions[1])
+}
+
+// '-'
+func (p *calcParser) exprLiteral4(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, "-")
+}
+
+// term <- number / '(' space expr ')' space {paren}
+func (p *calcParser) parseTerm(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.termMemo, "term")
+}
+
+// memoized rule term
+func (p *calcParser) termMemo(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMemo(pd, ctx, p.termBody, "term")
+}
+
+// number / '(' space expr ')' space {paren}
+func (p *calcParser) termBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAny(pd, ctx, p.termBodySubs, nil)
+}
+
+// '(' space expr ')' space {paren}
+func (p *calcParser) termSeq1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.termSeq1Subs, p.actions[2])
+}
+
+// '('
+func (p *calcParser) termLiteral2(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, "(")
+}
+
+// ')'
+func (p *calcParser) termLiteral3(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, ")")
+}
+
+// number <- [0-9]+ space {number}
+func (p *calcParser) parseNumber(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.numberMemo, "number")
+}
+
+// memoized rule number
+func (p *calcParser) numberMemo(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMemo(pd, ctx, p.numberBody, "number")
+}
+
+// [0-9]+ space {number}
+func (p *calcParser) numberBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.numberBodySubs, p.actions[3])
+}
+
+// [0-9]+
+func (p *calcParser) numberOneOrMore1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMulti1(pd, ctx, p.numberClass2, nil)
+}
+
+// [0-9]
+func (p *calcParser) numberClass2(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.regexps[1].ParseRegexp(pd, ctx, nil)
+}
+
+// space <- [ \t\n]*
+func (p *calcParser) parseSpace(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.spaceMemo, "space")
+}
+
+// memoized rule space
+func (p *calcParser) spaceMemo(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMemo(pd, ctx, p.spaceBody, "space")
+}
+
+// [ \t\n]*
+func (p *calcParser) spaceBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMulti0(pd, ctx, p.spaceClass1, nil)
+}
+
+// [ \t\n]
+func (p *calcParser) spaceClass1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.regexps[2].ParseRegexp(pd, ctx, nil)
+}
+
+// keyword <- ('if' / 'else') ![a-z]
+func (p *calcParser) parseKeyword(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.keywordMemo, "keyword")
+}
+
+// memoized rule keyword
+func (p *calcParser) keywordMemo(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMemo(pd, ctx, p.keywordBody, "keyword")
+}
+
+// ('if' / 'else') ![a-z]
+func (p *calcParser) keywordBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.keywordBodySubs, nil)
+}
+
+// 'if' / 'else'
+func (p *calcParser) keywordChoice1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAny(pd, ctx, p.keywordChoice1Subs, nil)
+}
+
+// 'if'
+func (p *calcParser) keywordLiteral2(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, "if")
+}
+
+// 'else'
+func (p *calcParser) keywordLiteral3(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, "else")
+}
+
+// ![a-z]
+func (p *calcParser) keywordNot4(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseNot(pd, ctx, p.keywordClass5, nil)
+}
+
+// [a-z]
+func (p *calcParser) keywordClass5(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.regexps[3].ParseRegexp(pd, ctx, nil)
+}
+
+// any <- &'a' . .? {text}
+func (p *calcParser) parseAny(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseRule(pd, ctx, p.anyMemo, "any")
+}
+
+// memoized rule any
+func (p *calcParser) anyMemo(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseMemo(pd, ctx, p.anyBody, "any")
+}
+
+// &'a' . .? {text}
+func (p *calcParser) anyBody(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAll(pd, ctx, p.anyBodySubs, p.actions[4])
+}
+
+// &'a'
+func (p *calcParser) anyAnd1(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseAnd(pd, ctx, p.anyLiteral2, nil)
+}
+
+// 'a'
+func (p *calcParser) anyLiteral2(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseLiteral(pd, ctx, nil, "a")
+}
+
+// .
+func (p *calcParser) anyAnyChar3(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.regexps[0].ParseRegexp(pd, ctx, nil)
+}
+
+// .?
+func (p *calcParser) anyOptional4(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return gparselib.ParseOptional(pd, ctx, p.anyAnyChar5, nil)
+}
+
+// .
+func (p *calcParser) anyAnyChar5(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+	return p.regexps[0].ParseRegexp(pd, ctx, nil)
+}
@@ -0,0 +1,65 @@
+// Command gparsegen generates a Go parser for a grammar written in PEG
+// notation.
+// The generated parser has a method for every rule and calls the parsers of
+// the gparselib package with its own methods as subparsers instead of
+// closures.
+//
+// Usage:
+//
+//	gparsegen [-o parser.go] [-pkg name] [-type Parser] grammar.peg
+//
+// It is meant to be used with go generate:
+//
+//	//go:generate go run github.com/flowdev/gparselib/cmd/gparsegen -type CalcParser calc.peg
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flowdev/gparselib"
+)
+
+func main() {
+	output := flag.String("o", "", "output file (default: <grammar>_parser.go)")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated parser")
+	typ := flag.String("type", "Parser", "type name of the generated parser")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gparsegen [flags] grammar.peg")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := generate(flag.Arg(0), *output, *pkg, *typ); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generate(grammarFile, output, pkg, typ string) error {
+	text, err := os.ReadFile(grammarFile)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = strings.TrimSuffix(grammarFile, filepath.Ext(grammarFile)) + "_parser.go"
+	}
+
+	buf := &bytes.Buffer{}
+	fb, err := gparselib.GenerateGo(buf, filepath.Base(grammarFile), string(text), pkg, typ)
+	if fb != "" {
+		fmt.Fprintln(os.Stderr, fb)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, buf.Bytes(), 0o644)
+}
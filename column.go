@@ -0,0 +1,208 @@
+package gparselib
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// ColumnMode is just an enumeration of the ways columns can be counted.
+type ColumnMode int
+
+// Enumeration of the ways we can count columns.
+const (
+	ColumnBytes     = ColumnMode(iota) // bytes (default)
+	ColumnRunes                        // Unicode code points
+	ColumnGraphemes                    // user-perceived characters (grapheme clusters)
+	ColumnVisual                       // display cells with expanded tabs
+)
+
+// defaultTabWidth is used for ColumnVisual if no valid tab width is given.
+const defaultTabWidth = 8
+
+// SetColumnMode sets the way columns are counted by LineCol, Offset, Where
+// and all feedback.
+// The tab width is only used for ColumnVisual; if it isn't positive,
+// 8 is used.
+func (sd *SourceData) SetColumnMode(mode ColumnMode, tabWidth int) {
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+	sd.colMode = mode
+	sd.tabWidth = tabWidth
+}
+
+// column returns the column (starting at 1) of the byte offset n in the line.
+// An offset in the middle of a character gives the column of the character.
+func (sd *SourceData) column(line string, n int) int {
+	if sd.colMode == ColumnBytes {
+		return n + 1
+	}
+	col := 1
+	for i := 0; i < n; {
+		size, width := sd.nextColumnUnit(line[i:], col)
+		if i+size > n {
+			break
+		}
+		i += size
+		col += width
+	}
+	return col
+}
+
+// columnOffset returns the byte offset of the column (starting at 1) in the
+// line.
+// A column in the middle of a wide character or tab gives the offset of that
+// character.
+// The column just after the end of the line is valid, too.
+func (sd *SourceData) columnOffset(line string, col int) (int, bool) {
+	if sd.colMode == ColumnBytes {
+		return col - 1, col >= 1 && col <= len(line)+1
+	}
+	cur := 1
+	for i := 0; i < len(line); {
+		size, width := sd.nextColumnUnit(line[i:], cur)
+		if col >= cur && col < cur+max(width, 1) {
+			return i, true
+		}
+		i += size
+		cur += width
+	}
+	return len(line), col == cur
+}
+
+// nextColumnUnit returns the size in bytes and the width in columns of the
+// first unit (rune, grapheme cluster, ...) in s.
+// The current column is needed for expanding tabs.
+func (sd *SourceData) nextColumnUnit(s string, col int) (size, width int) {
+	switch sd.colMode {
+	case ColumnRunes:
+		_, size = utf8.DecodeRuneInString(s)
+		return size, 1
+	case ColumnGraphemes:
+		return nextGrapheme(s), 1
+	case ColumnVisual:
+		if s[0] == '\t' {
+			return 1, sd.tabWidth - (col-1)%sd.tabWidth
+		}
+		size = nextGrapheme(s)
+		return size, graphemeWidth(s[:size])
+	default: // ColumnBytes
+		return 1, 1
+	}
+}
+
+// nextGrapheme returns the size in bytes of the first grapheme cluster in s.
+// It handles the most important rules of Unicode text segmentation
+// (UAX #29): CR LF, combining marks, variation selectors, emoji modifiers,
+// zero width joiner sequences and regional indicator pairs.
+func nextGrapheme(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == '\r' && len(s) > 1 && s[1] == '\n' {
+		return 2
+	}
+	if r == '\n' || r == '\r' {
+		return size
+	}
+	regional := isRegionalIndicator(r)
+	for size < len(s) {
+		next, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case next == zeroWidthJoiner:
+			size += n
+			if size < len(s) {
+				_, n = utf8.DecodeRuneInString(s[size:])
+				size += n
+			}
+		case unicode.In(next, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) ||
+			isEmojiModifier(next):
+			size += n
+		case regional && isRegionalIndicator(next):
+			size += n
+			regional = false
+		default:
+			return size
+		}
+	}
+	return size
+}
+
+// graphemeWidth returns the number of display cells of the grapheme cluster.
+func graphemeWidth(g string) int {
+	r, size := utf8.DecodeRuneInString(g)
+	switch {
+	case unicode.Is(wideRunes, r) || isRegionalIndicator(r):
+		return 2
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc):
+		return 0
+	}
+	for _, next := range g[size:] {
+		if next == emojiPresentation {
+			return 2
+		}
+	}
+	return 1
+}
+
+const (
+	zeroWidthJoiner   = '\u200D'
+	emojiPresentation = '\uFE0F'
+)
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+// wideRunes contains the runes that are displayed in two cells
+// (East Asian wide and fullwidth characters and emoji).
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115F, Stride: 1},
+		{Lo: 0x231A, Hi: 0x231B, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232A, Stride: 1},
+		{Lo: 0x23E9, Hi: 0x23EC, Stride: 1},
+		{Lo: 0x25FD, Hi: 0x25FE, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x26AA, Hi: 0x26AB, Stride: 1},
+		{Lo: 0x26BD, Hi: 0x26BE, Stride: 1},
+		{Lo: 0x26C4, Hi: 0x26C5, Stride: 1},
+		{Lo: 0x26F2, Hi: 0x26F3, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270A, Hi: 0x270B, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x2B1B, Hi: 0x2B1C, Stride: 1},
+		{Lo: 0x2E80, Hi: 0x303E, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33FF, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4DBF, Stride: 1},
+		{Lo: 0x4E00, Hi: 0x9FFF, Stride: 1},
+		{Lo: 0xA000, Hi: 0xA4CF, Stride: 1},
+		{Lo: 0xA960, Hi: 0xA97F, Stride: 1},
+		{Lo: 0xAC00, Hi: 0xD7A3, Stride: 1},
+		{Lo: 0xF900, Hi: 0xFAFF, Stride: 1},
+		{Lo: 0xFE10, Hi: 0xFE19, Stride: 1},
+		{Lo: 0xFE30, Hi: 0xFE6F, Stride: 1},
+		{Lo: 0xFF00, Hi: 0xFF60, Stride: 1},
+		{Lo: 0xFFE0, Hi: 0xFFE6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16FE0, Hi: 0x16FE4, Stride: 1},
+		{Lo: 0x17000, Hi: 0x18CFF, Stride: 1},
+		{Lo: 0x1B000, Hi: 0x1B2FF, Stride: 1},
+		{Lo: 0x1F004, Hi: 0x1F004, Stride: 1},
+		{Lo: 0x1F0CF, Hi: 0x1F0CF, Stride: 1},
+		{Lo: 0x1F18E, Hi: 0x1F18E, Stride: 1},
+		{Lo: 0x1F191, Hi: 0x1F19A, Stride: 1},
+		{Lo: 0x1F200, Hi: 0x1F251, Stride: 1},
+		{Lo: 0x1F300, Hi: 0x1F64F, Stride: 1},
+		{Lo: 0x1F680, Hi: 0x1F6FF, Stride: 1},
+		{Lo: 0x1F7E0, Hi: 0x1F7EB, Stride: 1},
+		{Lo: 0x1F90C, Hi: 0x1F9FF, Stride: 1},
+		{Lo: 0x1FA70, Hi: 0x1FAFF, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2FFFD, Stride: 1},
+		{Lo: 0x30000, Hi: 0x3FFFD, Stride: 1},
+	},
+}
@@ -0,0 +1,101 @@
+package gparselib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSourceData_ColumnModes(t *testing.T) {
+	// "ä" is 2 bytes, "é" is 2 runes but 1 grapheme, "日本" are wide,
+	// "👍🏽" is 1 grapheme of 2 runes (8 bytes) with a width of 2.
+	content := "x\n\tä é 日本 👍🏽 y"
+	specs := []struct {
+		name     string
+		mode     ColumnMode
+		tabWidth int
+		expected int // column of 'y'
+	}{
+		{name: "bytes", mode: ColumnBytes, expected: 25},
+		{name: "runes", mode: ColumnRunes, expected: 13},
+		{name: "graphemes", mode: ColumnGraphemes, expected: 11},
+		{name: "visual default tab width", mode: ColumnVisual, expected: 21},
+		{name: "visual tab width 4", mode: ColumnVisual, tabWidth: 4, expected: 17},
+	}
+	offset := strings.IndexByte(content, 'y')
+
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			sd := NewSourceData("test", content)
+			sd.SetColumnMode(spec.mode, spec.tabWidth)
+
+			line, col := sd.LineCol(offset)
+			if line != 2 || col != spec.expected {
+				t.Errorf("expected line 2, column %d, got: line %d, column %d", spec.expected, line, col)
+			}
+			actualOffset, err := sd.Offset(2, spec.expected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actualOffset != offset {
+				t.Errorf("expected offset %d, got: %d", offset, actualOffset)
+			}
+			if _, err = sd.Offset(2, spec.expected+2); err == nil {
+				t.Errorf("expected an error for a column after the end of the line")
+			}
+			w := sd.Where(offset)
+			if !strings.Contains(w, "line 2") || !strings.Contains(w, fmt.Sprintf("column %d:", spec.expected)) {
+				t.Errorf("expected line 2, column %d in where message, got: %q", spec.expected, w)
+			}
+		})
+	}
+}
+
+func TestSourceData_ColumnInsideOfCharacter(t *testing.T) {
+	sd := NewSourceData("test", "\t日x")
+	sd.SetColumnMode(ColumnVisual, 4)
+
+	specs := []struct {
+		col, offset int
+	}{
+		{col: 1, offset: 0},
+		{col: 3, offset: 0},
+		{col: 5, offset: 1},
+		{col: 6, offset: 1},
+		{col: 7, offset: 4},
+		{col: 8, offset: 5},
+	}
+	for _, spec := range specs {
+		offset, err := sd.Offset(1, spec.col)
+		if err != nil {
+			t.Errorf("column %d: unexpected error: %v", spec.col, err)
+		} else if offset != spec.offset {
+			t.Errorf("column %d: expected offset %d, got: %d", spec.col, spec.offset, offset)
+		}
+	}
+	if _, col := sd.LineCol(2); col != 5 {
+		t.Errorf("expected the column of the wide character (5) for an offset inside of it, got: %d", col)
+	}
+}
+
+func TestNextGrapheme(t *testing.T) {
+	specs := []struct {
+		name     string
+		given    string
+		expected int
+	}{
+		{name: "ASCII", given: "ab", expected: 1},
+		{name: "CR LF", given: "\r\nx", expected: 2},
+		{name: "combining mark", given: "é̂x", expected: 5},
+		{name: "ZWJ sequence", given: "👩‍💻x", expected: 11},
+		{name: "regional indicator pairs", given: "🇩🇪🇫🇷", expected: 8},
+		{name: "emoji with variation selector", given: "☺️x", expected: 6},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			if actual := nextGrapheme(spec.given); actual != spec.expected {
+				t.Errorf("expected %d bytes, got: %d", spec.expected, actual)
+			}
+		})
+	}
+}
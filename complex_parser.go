@@ -13,18 +13,32 @@ func ParseMulti(
 	pluginSubparser SubparserOp, pluginSemantics SemanticsOp,
 	cfgMin, cfgMax int,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindMulti, Min: cfgMin, Max: cfgMax}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "multi"))
+	}
 	orgPos := pd.Source.pos
 	relPos := 0
 	subresults := make([]*ParseResult, 0, min(cfgMax, 128))
 
 	for i := 0; i < cfgMax && pd.Result == nil; i++ {
+		pd.backtracks++
 		pd, ctx = pluginSubparser(pd, ctx)
+		pd.backtracks--
 		if !pd.Result.HasError() {
 			subresults = append(subresults, pd.Result)
 			pd.Result = nil
 		}
 	}
 
+	if pd.Result != nil && pd.Result.HardError {
+		pd.Source.pos = orgPos
+		pd.Result.Pos = orgPos // make result 'our result'
+		saveAllFeedback(pd, subresults)
+		return pd, ctx
+	}
 	var lastFeedback []*FeedbackItem
 	if pd.Result != nil {
 		lastFeedback = pd.Result.Feedback
@@ -48,6 +62,7 @@ func ParseMulti(
 			),
 			nil,
 		)
+		expectAlternatives(pd, subresult.Feedback)
 		pd.Result.Feedback = append(pd.Result.Feedback, subresult.Feedback...)
 	}
 	pd.SubResults = subresults
@@ -101,15 +116,28 @@ func NewParseMulti1Plugin(pluginSubparser SubparserOp, pluginSemantics Semantics
 }
 
 // ParseOptional uses its subparser exaclty one time.
-// But the result is still positive even if the subparser didn't match.
+// But the result is still positive even if the subparser didn't match
+// (unless it failed after a cut).
 func ParseOptional(
 	pd *ParseData, ctx interface{},
 	pluginSubparser SubparserOp, pluginSemantics SemanticsOp,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindOptional}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "optional"))
+	}
 	orgPos := pd.Source.pos
 
+	pd.backtracks++
 	pd, ctx = pluginSubparser(pd, ctx)
+	pd.backtracks--
 
+	if pd.Result.HardError {
+		pd.Source.pos = orgPos
+		return pd, ctx
+	}
 	// if error: reset to ignore but save valuable feedback
 	if pd.Result.HasError() {
 		fb := pd.Result.Feedback
@@ -129,17 +157,111 @@ func NewParseOptionalPlugin(pluginSubparser SubparserOp, pluginSemantics Semanti
 	}
 }
 
+// ParseNot is a negative lookahead (PEG: `!e`).
+// It uses its subparser exactly one time and only matches if the subparser
+// doesn't match.
+// It never consumes any input.
+func ParseNot(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp, pluginSemantics SemanticsOp,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindNot}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "not"))
+	}
+	orgPos := pd.Source.pos
+
+	pd.predicates++
+	pd, ctx = pluginSubparser(pd, ctx)
+	pd.predicates--
+
+	subresult := pd.Result
+	pd.Source.pos = orgPos
+	pd.Result = nil
+	if subresult.HasError() {
+		createMatchedResult(pd, 0)
+	} else {
+		createUnmatchedResult(
+			pd, 0,
+			"Subparser shouldn't match but matched '"+subresult.Text+"'",
+			nil,
+		)
+	}
+	pd.Result.Feedback = addPotentialProblems(pd.Result.Feedback, subresult.Feedback)
+	return handleSemantics(pluginSemantics, pd, ctx)
+}
+
+// NewParseNotPlugin creates a plugin sporting a negative lookahead parser.
+func NewParseNotPlugin(pluginSubparser SubparserOp, pluginSemantics SemanticsOp) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseNot(pd, ctx, pluginSubparser, pluginSemantics)
+	}
+}
+
+// ParseAnd is a positive lookahead (PEG: `&e`).
+// It uses its subparser exactly one time and only matches if the subparser
+// matches.
+// It never consumes any input but keeps the value of the subparser.
+func ParseAnd(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp, pluginSemantics SemanticsOp,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindAnd}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "and"))
+	}
+	orgPos := pd.Source.pos
+
+	pd.predicates++
+	pd, ctx = pluginSubparser(pd, ctx)
+	pd.predicates--
+
+	subresult := pd.Result
+	pd.Source.pos = orgPos
+	pd.Result = nil
+	if subresult.HasError() {
+		createUnmatchedResult(pd, subresult.ErrPos-orgPos, "Subparser should match", nil)
+		pd.Result.Feedback = append(pd.Result.Feedback, subresult.Feedback...)
+	} else {
+		createMatchedResult(pd, 0)
+		pd.Result.Value = subresult.Value
+		pd.Result.Feedback = addPotentialProblems(pd.Result.Feedback, subresult.Feedback)
+	}
+	return handleSemantics(pluginSemantics, pd, ctx)
+}
+
+// NewParseAndPlugin creates a plugin sporting a positive lookahead parser.
+func NewParseAndPlugin(pluginSubparser SubparserOp, pluginSemantics SemanticsOp) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseAnd(pd, ctx, pluginSubparser, pluginSemantics)
+	}
+}
+
 // ParseAll calls multiple subparsers and all have to match for a successful result.
 func ParseAll(
 	pd *ParseData, ctx interface{},
 	pluginSubparsers []SubparserOp, pluginSemantics SemanticsOp,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindAll}, pluginSubparsers...)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "all"))
+	}
 	orgPos := pd.Source.pos
 	subresults := make([]*ParseResult, len(pluginSubparsers))
+	parentCut := pd.cut
+	pd.cut = false
 
 	for i, subparser := range pluginSubparsers {
 		pd, ctx = subparser(pd, ctx)
 		if pd.Result.HasError() {
+			pd.Result.HardError = pd.Result.HardError || pd.cut
+			pd.cut = parentCut
 			pd.Source.pos = orgPos
 			pd.Result.Pos = orgPos // make result 'our result'
 			saveAllFeedback(pd, subresults)
@@ -148,6 +270,7 @@ func ParseAll(
 		subresults[i] = pd.Result
 		pd.Result = nil
 	}
+	pd.cut = parentCut
 
 	relPos := pd.Source.pos - orgPos
 	pd.Source.pos = orgPos
@@ -172,15 +295,32 @@ func ParseAny(
 	pd *ParseData, ctx interface{},
 	pluginSubparsers []SubparserOp, pluginSemantics SemanticsOp,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindAny}, pluginSubparsers...)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnterAlternatives(pd, "any", len(pluginSubparsers)))
+	}
 	orgPos := pd.Source.pos
 	subresults := make([]*ParseResult, 0, len(pluginSubparsers))
 	lastPos := 0
 
-	for _, subparser := range pluginSubparsers {
-		pd, ctx = subparser(pd, ctx)
+	for i, subparser := range pluginSubparsers {
+		pd.backtracks++
+		if pd.Tracer != nil {
+			pd, ctx = traceAlternative(pd, ctx, subparser, i)
+		} else {
+			pd, ctx = subparser(pd, ctx)
+		}
+		pd.backtracks--
 		if !pd.Result.HasError() {
 			return handleSemantics(pluginSemantics, pd, ctx)
 		}
+		if pd.Result.HardError {
+			pd.Source.pos = orgPos
+			pd.Result.Pos = orgPos // make result 'our result'
+			return pd, ctx
+		}
 		lastPos = max(lastPos, pd.Result.Pos)
 		pd.Source.pos = orgPos
 		subresults = append(subresults, pd.Result)
@@ -198,6 +338,9 @@ func ParseAny(
 		),
 		nil,
 	)
+	for _, subres := range subresults {
+		expectAlternatives(pd, subres.Feedback)
+	}
 	saveBestFeedback(pd, subresults)
 	return pd, ctx
 }
@@ -218,14 +361,31 @@ func ParseBest(
 	pd *ParseData, ctx interface{},
 	pluginSubparsers []SubparserOp, pluginSemantics SemanticsOp,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindBest}, pluginSubparsers...)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnterAlternatives(pd, "best", len(pluginSubparsers)))
+	}
 	orgPos := pd.Source.pos
 	allFeedback := make([]*FeedbackItem, 0, len(pluginSubparsers))
 	lastPos := 0
 	bestNewSourcePos := 0
 	var bestResult *ParseResult
 
-	for _, subparser := range pluginSubparsers {
-		pd, ctx = subparser(pd, ctx)
+	for i, subparser := range pluginSubparsers {
+		pd.backtracks++
+		if pd.Tracer != nil {
+			pd, ctx = traceAlternative(pd, ctx, subparser, i)
+		} else {
+			pd, ctx = subparser(pd, ctx)
+		}
+		pd.backtracks--
+		if pd.Result.HardError {
+			pd.Source.pos = orgPos
+			pd.Result.Pos = orgPos // make result 'our result'
+			return pd, ctx
+		}
 		if !pd.Result.HasError() {
 			if pd.Source.pos > bestNewSourcePos {
 				bestNewSourcePos = pd.Source.pos
@@ -254,6 +414,7 @@ func ParseBest(
 		),
 		nil,
 	)
+	expectAlternatives(pd, allFeedback)
 	pd.Result.Feedback = append(pd.Result.Feedback, allFeedback...)
 	return pd, ctx
 }
@@ -315,31 +476,39 @@ func maxFeedbackPos(feedback []*FeedbackItem) int {
 	return maxPos
 }
 
-func addPotentialProblems(feedback []*FeedbackItem, potentialFeedback []*FeedbackItem) []*FeedbackItem {
-	if potentialFeedback == nil {
-		return feedback
+// newPotentialProblem creates a message for a potential problem from a parse
+// error without rendering its position.
+func newPotentialProblem(pe *ParseError, msg string) *parseMessage {
+	if pe.src != nil {
+		return &parseMessage{src: pe.src, pos: pe.Offset, rule: pe.Rule, msg: msg}
 	}
+	return &parseMessage{pos: pe.Offset, where: pe.where(), rule: pe.Rule, msg: msg}
+}
+func addPotentialProblems(feedback []*FeedbackItem, potentialFeedback []*FeedbackItem) []*FeedbackItem {
 	for _, pf := range potentialFeedback {
-		if pf.Kind != FeedbackPotentialProblem {
-			if pf.Kind == FeedbackError {
-				pe := pf.Msg.(*parseError)
-				msg := pe.myErr
-				if pe.baseErr != nil {
-					msg += ": " + pe.baseErr.Error()
-				}
-				pm := &parseMessage{
-					where: pe.where,
-					msg:   msg,
-				}
-				pf.Msg = pm
-				pf.Kind = FeedbackPotentialProblem
-			} else if pf.Kind == FeedbackWarning {
-				pm := pf.Msg.(*parseMessage)
-				pm.msg = "potential problem: " + pm.msg
-				pf.Msg = pm
-				pf.Kind = FeedbackPotentialProblem
+		if pf.Kind.isError() {
+			pe := pf.Msg.(*ParseError)
+			msg := pe.Msg
+			if pe.Err != nil {
+				msg += ": " + pe.Err.Error()
+			}
+			pf = &FeedbackItem{
+				Pos:  pf.Pos,
+				Kind: FeedbackPotentialProblem,
+				Msg:  newPotentialProblem(pe, msg),
+			}
+		} else if pf.Kind == FeedbackWarning {
+			pm := pf.Msg.(*parseMessage)
+			pf = &FeedbackItem{
+				Pos:  pf.Pos,
+				Kind: FeedbackPotentialProblem,
+				Msg: &parseMessage{
+					src: pm.src, pos: pm.pos, where: pm.where, rule: pm.rule,
+					msg: "potential problem: " + pm.msg,
+				},
 			}
 		}
+		feedback = append(feedback, pf)
 	}
-	return append(feedback, potentialFeedback...)
+	return feedback
 }
@@ -1,6 +1,7 @@
 package gparselib
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -27,6 +28,80 @@ func TestParseOptional(t *testing.T) {
 	})
 }
 
+func TestParseNot(t *testing.T) {
+	p := NewParseNotPlugin(NewParseLiteralPlugin(nil, "flow"), nil)
+
+	runTests(t, p, []parseTestData{
+		{
+			givenParseData:   newData("empty", 0, ""),
+			expectedResult:   newResult(0, "", nil, -1),
+			expectedSrcPos:   0,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("no match", 1, "1 flow"),
+			expectedResult:   newResult(1, "", nil, -1),
+			expectedSrcPos:   1,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("match", 2, "12flow"),
+			expectedResult:   newResult(2, "", nil, 2),
+			expectedSrcPos:   2,
+			expectedErrCount: 1,
+		},
+	})
+
+	pd, _ := p(newData("potential problem", 0, "flop"), nil)
+	if len(pd.Result.Feedback) != 1 || pd.Result.Feedback[0].Kind != FeedbackPotentialProblem {
+		t.Errorf("expected exactly one potential problem, got: %s", printErrors(pd.Result.Feedback))
+	}
+}
+
+func TestParseAnd(t *testing.T) {
+	p := NewParseAndPlugin(NewParseLiteralPlugin(SemanticsTestOp, "flow"), nil)
+
+	runTests(t, p, []parseTestData{
+		{
+			givenParseData:   newData("empty", 0, ""),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 2,
+		}, {
+			givenParseData:   newData("no match", 1, "1 flow"),
+			expectedResult:   newResult(1, "", nil, 1),
+			expectedSrcPos:   1,
+			expectedErrCount: 2,
+		}, {
+			givenParseData:   newData("match", 2, "12flow"),
+			expectedResult:   newResult(2, "", semanticTestValue, -1),
+			expectedSrcPos:   2,
+			expectedErrCount: 0,
+		},
+	})
+}
+
+func TestParseNot_InAll(t *testing.T) {
+	plIdent := NewParseIdentPlugin(nil, "", "")
+	plKeyword := NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "if"),
+		NewParseNotPlugin(plIdent, nil),
+	}, nil)
+	p := NewParseAllPlugin([]SubparserOp{NewParseNotPlugin(plKeyword, nil), plIdent}, nil)
+
+	runTests(t, p, []parseTestData{
+		{
+			givenParseData:   newData("keyword", 0, "if"),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 1,
+		}, {
+			givenParseData:   newData("ident", 0, "iffy"),
+			expectedResult:   newResult(0, "iffy", []interface{}{nil, nil}, -1),
+			expectedSrcPos:   4,
+			expectedErrCount: 0,
+		},
+	})
+}
+
 func TestParseMulti0(t *testing.T) {
 	p := NewParseMulti0Plugin(NewParseLiteralPlugin(nil, "flow"), nil)
 
@@ -331,3 +406,82 @@ func TestParseBest_Nested(t *testing.T) {
 		},
 	})
 }
+
+func TestFurthestError(t *testing.T) {
+	pIf := NewParseLiteralPlugin(nil, "if")
+	pFor := NewParseLiteralPlugin(nil, "for")
+	pIdent := NewParseIdentPlugin(nil, "", "")
+	pStmt := NewParseAnyPlugin([]SubparserOp{pIf, pFor, pIdent, pIf}, nil)
+	pSpace := NewParseSpacePlugin(nil, true)
+	pBlock := NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "{"),
+		NewParseMulti0Plugin(NewParseAnyPlugin([]SubparserOp{pSpace, pStmt}, nil), nil),
+		NewParseNotPlugin(NewParseLiteralPlugin(nil, "}"), nil),
+		NewParseLiteralPlugin(nil, "]"),
+	}, nil)
+
+	pd := NewParseData("test", "{\n  if\n  for }")
+	if pd.FurthestError() != nil {
+		t.Fatalf("expected no furthest error before parsing, got: %v", pd.FurthestError())
+	}
+	pd, _ = pBlock(pd, nil)
+	if !pd.Result.HasError() {
+		t.Fatalf("expected an error, got: %#v", pd.Result)
+	}
+	pe := pd.FurthestError()
+	if pe == nil {
+		t.Fatalf("expected a furthest error, got nil")
+	}
+	if pe.Offset != 13 || pe.Line() != 3 || pe.Column() != 7 {
+		t.Errorf("expected furthest error at offset 13 (line 3, column 7), got: %d (line %d, column %d)",
+			pe.Offset, pe.Line(), pe.Column())
+	}
+	expectedMsg := "expected one of white space, 'if', 'for', identifier; found '}'"
+	if pe.Msg != expectedMsg {
+		t.Errorf("expected message %q, got: %q", expectedMsg, pe.Msg)
+	}
+	if len(pe.Expected) != 4 {
+		t.Errorf("expected 4 expectations, got: %q", pe.Expected)
+	}
+
+	pd = NewParseData("test", "")
+	pd, _ = pIf(pd, nil)
+	expectedMsg = "expected 'if'; found end of input"
+	if pe = pd.FurthestError(); pe == nil || pe.Msg != expectedMsg {
+		t.Errorf("expected message %q, got: %v", expectedMsg, pe)
+	}
+}
+
+func BenchmarkParseAny_Alternatives(b *testing.B) {
+	benchmarkTokens(b, nil)
+}
+
+// benchmarkTokens parses keywords and identifiers with the tracer set.
+func benchmarkTokens(b *testing.B, tracer Tracer) {
+	keywords := []string{"break", "case", "const", "continue", "default", "else", "for", "func", "if", "return"}
+	alternatives := make([]SubparserOp, 0, len(keywords)+1)
+	for _, kw := range keywords {
+		alternatives = append(alternatives, NewParseLiteralPlugin(nil, kw))
+	}
+	alternatives = append(alternatives, NewParseIdentPlugin(nil, "_", "_"))
+	pToken := NewParseAnyPlugin(alternatives, nil)
+	pTokens := NewParseMulti0Plugin(
+		NewParseAllPlugin([]SubparserOp{
+			pToken,
+			NewParseOptionalPlugin(NewParseSpacePlugin(nil, true), nil),
+		}, nil),
+		nil,
+	)
+	content := strings.Repeat("foo bar return x\nbaz if qux\n", 200)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pd := NewParseData("bench", content)
+		pd.Tracer = tracer
+		pd, _ = pTokens(pd, nil)
+		if pd.Result.HasError() {
+			b.Fatalf("unexpected error: %v", pd.Result.Feedback)
+		}
+	}
+}
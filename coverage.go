@@ -0,0 +1,264 @@
+package gparselib
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RuleCoverage contains the coverage of a single grammar rule.
+type RuleCoverage struct {
+	Rule     string
+	Matches  int
+	Failures int
+}
+
+// AlternativeCoverage contains the coverage of a single alternative of a
+// ParseAny or ParseBest.
+type AlternativeCoverage struct {
+	Rule   string // name of the grammar rule containing the parser
+	Parser string // "any" or "best"
+	// Path is the position of the parser in its rule: the indexes of the
+	// subparsers leading to it separated by dots (e.g.: "1.0").
+	// It is empty if the rule consists of the parser.
+	// Memoization, left recursion and labels don't count.
+	Path     string
+	Index    int // index of the alternative
+	Matches  int
+	Failures int
+}
+
+// Coverage is a Tracer that records which rules and which alternatives of
+// ParseAny and ParseBest matched or failed.
+// It has to be set in ParseData.Tracer and can collect the coverage of many
+// parses (e.g.: of a whole test suite).
+type Coverage struct {
+	rules map[string]*RuleCoverage
+	alts  map[altKey]*AlternativeCoverage
+	stack []coverageFrame
+}
+
+type altKey struct {
+	rule, path string
+	index      int
+}
+
+type coverageFrame struct {
+	parser   string
+	rule     string
+	path     string
+	children int                  // number of subparsers called so far
+	alt      *AlternativeCoverage // if the parser is an alternative
+}
+
+// NewCoverage creates a new, empty Coverage.
+// If the grammar isn't nil, all of its rules and their alternatives are
+// reported even if they are never called (see Grammar.Describe).
+// Alternatives inside of parsers that aren't from this package are only
+// known after they have been called.
+func NewCoverage(g *Grammar) *Coverage {
+	c := &Coverage{
+		rules: make(map[string]*RuleCoverage, 64),
+		alts:  make(map[altKey]*AlternativeCoverage, 128),
+	}
+	if g != nil {
+		for _, d := range g.Describe() {
+			c.rule(d.Name)
+			c.describedAlternatives(d, d.Name, "")
+		}
+	}
+	return c
+}
+
+// describedAlternatives adds all alternatives of the described parser and
+// its subparsers with the same paths as Enter computes them.
+func (c *Coverage) describedAlternatives(d *Descriptor, rule, path string) {
+	switch d.Kind {
+	case KindRef, KindCustom:
+		return
+	case KindAny, KindBest:
+		for i := range d.Children {
+			c.alternative(rule, d.Kind.String(), path, i)
+		}
+	}
+	for i, child := range d.Children {
+		index := i
+		switch d.Kind {
+		case KindMulti, KindStream, KindLeftRec:
+			index = 0
+		case KindRecover:
+			index = min(index, 1)
+		}
+		switch {
+		case d.Kind == KindRule || d.Kind == KindMemo || d.Kind == KindLeftRec || d.Kind == KindLabel:
+			c.describedAlternatives(child, rule, path)
+		case path == "":
+			c.describedAlternatives(child, rule, strconv.Itoa(index))
+		default:
+			c.describedAlternatives(child, rule, path+"."+strconv.Itoa(index))
+		}
+	}
+}
+
+// Enter records the position of the parser in its rule.
+func (c *Coverage) Enter(ev TraceEvent) {
+	frame := coverageFrame{parser: ev.Parser, rule: ev.Rule}
+	if n := len(c.stack); n > 0 {
+		parent := &c.stack[n-1]
+		index := parent.children
+		switch {
+		case parent.parser == "multi" || parent.parser == "stream" ||
+			strings.HasPrefix(parent.parser, "left recursion"):
+			index = 0 // the same subparser is called again and again
+		case parent.parser == "recover":
+			index = min(index, 1) // the synchronization parser is called again and again
+		}
+		parent.children++
+		if strings.HasPrefix(ev.Parser, "alternative ") {
+			index = ev.Index // ParseAny and ParseBest know which alternative they call
+			frame.alt = c.alternative(parent.rule, parent.parser, parent.path, index)
+		}
+		switch {
+		case ev.Parser == "rule":
+			frame.path = ""
+		case isWrapper(parent.parser):
+			frame.path = parent.path
+		case parent.path == "":
+			frame.path = strconv.Itoa(index)
+		default:
+			frame.path = parent.path + "." + strconv.Itoa(index)
+		}
+	}
+	if ev.Parser == "any" || ev.Parser == "best" {
+		for i := 0; i < ev.Alternatives; i++ {
+			c.alternative(ev.Rule, ev.Parser, frame.path, i)
+		}
+	}
+	c.stack = append(c.stack, frame)
+}
+
+// Exit records the success of the parser.
+func (c *Coverage) Exit(ev TraceEvent) {
+	if len(c.stack) == 0 {
+		return
+	}
+	frame := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	if ev.Parser == "rule" {
+		rc := c.rule(ev.Rule)
+		if ev.Success {
+			rc.Matches++
+		} else {
+			rc.Failures++
+		}
+	}
+	if frame.alt != nil {
+		if ev.Success {
+			frame.alt.Matches++
+		} else {
+			frame.alt.Failures++
+		}
+	}
+}
+
+// Rules returns the coverage of all rules sorted by name.
+func (c *Coverage) Rules() []RuleCoverage {
+	rules := make([]RuleCoverage, 0, len(c.rules))
+	for _, rc := range c.rules {
+		rules = append(rules, *rc)
+	}
+	slices.SortFunc(rules, func(a, b RuleCoverage) int {
+		return strings.Compare(a.Rule, b.Rule)
+	})
+	return rules
+}
+
+// Alternatives returns the coverage of all alternatives sorted by rule, path
+// and index.
+func (c *Coverage) Alternatives() []AlternativeCoverage {
+	alts := make([]AlternativeCoverage, 0, len(c.alts))
+	for _, ac := range c.alts {
+		alts = append(alts, *ac)
+	}
+	slices.SortFunc(alts, func(a, b AlternativeCoverage) int {
+		return cmp.Or(
+			strings.Compare(a.Rule, b.Rule),
+			strings.Compare(a.Path, b.Path),
+			cmp.Compare(a.Index, b.Index),
+		)
+	})
+	return alts
+}
+
+// WriteReport writes the percentage of matched alternatives and lists all
+// rules and alternatives that never matched, e.g.:
+//
+//	coverage: 75.0% of alternatives matched (3 of 4)
+//	rule 'expr', alternative 1 of any: never matched (failed 2 times)
+//	rule 'keyword': never called
+func (c *Coverage) WriteReport(w io.Writer) error {
+	alts := c.Alternatives()
+	matched := 0
+	for _, ac := range alts {
+		if ac.Matches > 0 {
+			matched++
+		}
+	}
+	percent := 100.0
+	if len(alts) > 0 {
+		percent = float64(matched) * 100 / float64(len(alts))
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "coverage: %.1f%% of alternatives matched (%d of %d)\n", percent, matched, len(alts))
+	for _, rc := range c.Rules() {
+		if rc.Matches == 0 {
+			fmt.Fprintf(b, "rule '%s': %s\n", rc.Rule, neverMatched(rc.Failures, "called"))
+		}
+	}
+	for _, ac := range alts {
+		if ac.Matches > 0 {
+			continue
+		}
+		fmt.Fprintf(b, "rule '%s', alternative %d of %s", ac.Rule, ac.Index, ac.Parser)
+		if ac.Path != "" {
+			fmt.Fprintf(b, " at %s", ac.Path)
+		}
+		fmt.Fprintf(b, ": %s\n", neverMatched(ac.Failures, "tried"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// isWrapper returns true for parsers that don't add structure to a rule.
+func isWrapper(parser string) bool {
+	return parser == "rule" || strings.HasPrefix(parser, "alternative ") || strings.HasPrefix(parser, "memo ") ||
+		strings.HasPrefix(parser, "left recursion ") || strings.HasPrefix(parser, "label ")
+}
+
+func neverMatched(failures int, used string) string {
+	if failures == 0 {
+		return "never " + used
+	}
+	return fmt.Sprintf("never matched (failed %d times)", failures)
+}
+
+func (c *Coverage) rule(name string) *RuleCoverage {
+	rc := c.rules[name]
+	if rc == nil {
+		rc = &RuleCoverage{Rule: name}
+		c.rules[name] = rc
+	}
+	return rc
+}
+func (c *Coverage) alternative(rule, parser, path string, index int) *AlternativeCoverage {
+	key := altKey{rule, path, index}
+	ac := c.alts[key]
+	if ac == nil {
+		ac = &AlternativeCoverage{Rule: rule, Parser: parser, Path: path, Index: index}
+		c.alts[key] = ac
+	}
+	return ac
+}
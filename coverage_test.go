@@ -0,0 +1,122 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	g := loadCalcGrammar(t)
+	calc := g.Start()
+	c := NewCoverage(g)
+	for _, input := range []string{"1 + 2", "(3)", "4 +"} {
+		pd := NewParseData(input, input)
+		pd.Tracer = c
+		calc(pd, nil)
+	}
+
+	alts := c.Alternatives()
+	if len(alts) != 7 {
+		t.Fatalf("expected 7 alternatives, got: %+v", alts)
+	}
+	// keyword <- ("if" / "else") ![a-z]
+	for i, ac := range alts[3:5] {
+		if ac.Rule != "keyword" || ac.Parser != "any" || ac.Path != "0" || ac.Index != i || ac.Matches+ac.Failures != 0 {
+			t.Errorf("expected never tried alternative %d of the rule 'keyword', got: %+v", i, ac)
+		}
+	}
+	// term <- number / '(' space expr ')' space
+	for i, ac := range alts[5:] {
+		if ac.Rule != "term" || ac.Parser != "any" || ac.Path != "" || ac.Index != i || ac.Matches == 0 {
+			t.Errorf("expected matched alternative %d of the rule 'term', got: %+v", i, ac)
+		}
+	}
+	for _, rc := range c.Rules() {
+		if rc.Rule == "calc" && (rc.Matches != 2 || rc.Failures != 1) {
+			t.Errorf("expected 2 matches and 1 failure of the start rule, got: %+v", rc)
+		}
+	}
+
+	b := &strings.Builder{}
+	if err := c.WriteReport(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `coverage: 57.1% of alternatives matched (4 of 7)
+rule 'any': never called
+rule 'keyword': never called
+rule 'expr', alternative 1 of any: never matched (failed 10 times)
+rule 'keyword', alternative 0 of any at 0: never tried
+rule 'keyword', alternative 1 of any at 0: never tried
+`
+	if b.String() != expected {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestCoverage_Nested(t *testing.T) {
+	pA := NewParseLiteralPlugin(nil, "a")
+	pB := NewParseLiteralPlugin(nil, "b")
+	p := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseRule(pd, ctx, NewParseAllPlugin([]SubparserOp{
+			pA,
+			NewParseMulti0Plugin(NewParseBestPlugin([]SubparserOp{pA, pB}, nil), nil),
+		}, nil), "list")
+	}
+	c := NewCoverage(nil)
+	pd := NewParseData("test", "aaa")
+	pd.Tracer = c
+	pd, _ = p(pd, nil)
+	if pd.Result.HasError() {
+		t.Fatalf("unexpected errors: %s", printErrors(pd.Result.Feedback))
+	}
+
+	alts := c.Alternatives()
+	if len(alts) != 2 {
+		t.Fatalf("expected 2 alternatives, got: %+v", alts)
+	}
+	for i, ac := range alts {
+		if ac.Rule != "list" || ac.Parser != "best" || ac.Path != "1.0" || ac.Index != i {
+			t.Errorf("unexpected alternative: %+v", ac)
+		}
+	}
+	if alts[0].Matches != 2 || alts[0].Failures != 1 || alts[1].Matches != 0 || alts[1].Failures != 3 {
+		t.Errorf("unexpected coverage: %+v", alts)
+	}
+}
+
+func TestCoverage_CustomAlternative(t *testing.T) {
+	pX := NewParseLiteralPlugin(nil, "x")
+	pY := NewParseLiteralPlugin(nil, "y")
+	pZ := NewParseLiteralPlugin(nil, "z")
+	custom := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		orgPos := pd.Source.pos
+		pd, ctx = pX(pd, ctx)
+		if pd.Result.HasError() {
+			return pd, ctx
+		}
+		pd, ctx = pY(pd, ctx)
+		pd.Result.Pos = orgPos
+		return pd, ctx
+	}
+	p := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseRule(pd, ctx, NewParseAnyPlugin([]SubparserOp{custom, pZ}, nil), "pair")
+	}
+	c := NewCoverage(nil)
+	for _, input := range []string{"xy", "z"} {
+		pd := NewParseData(input, input)
+		pd.Tracer = c
+		pd, _ = p(pd, nil)
+		if pd.Result.HasError() {
+			t.Fatalf("unexpected errors for %q: %s", input, printErrors(pd.Result.Feedback))
+		}
+	}
+
+	alts := c.Alternatives()
+	if len(alts) != 2 {
+		t.Fatalf("expected 2 alternatives, got: %+v", alts)
+	}
+	// the custom alternative calls two parsers but it is still only the first alternative
+	if alts[0].Matches != 1 || alts[0].Failures != 1 || alts[1].Matches != 1 || alts[1].Failures != 0 {
+		t.Errorf("unexpected coverage: %+v", alts)
+	}
+}
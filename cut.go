@@ -0,0 +1,47 @@
+package gparselib
+
+// ParseCut commits the enclosing ParseAll to its alternative (PEG: `^`).
+// It always matches without consuming any input.
+// If a later subparser of the enclosing ParseAll fails, the failure is a hard
+// error (ParseResult.HardError): ParseAny and ParseBest don't try other
+// alternatives and ParseOptional, ParseMulti and ParseStream don't ignore it.
+// So the error message is about the committed alternative and not
+// "all subparsers failed".
+// Only predicates (ParseNot, ParseAnd) and ParseRecover stop hard errors.
+// ParseCut has to be used directly as a subparser of ParseAll.
+//
+// If no parser can backtrack to content before the cut anymore, memoized
+// results and streamed content before the cut are released.
+func ParseCut(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindCut})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "cut"))
+	}
+	pd.cut = true
+	if pd.backtracks == 0 && pd.predicates == 0 {
+		pd.release(pd.Source.pos)
+	}
+	createMatchedResult(pd, 0)
+	return pd, ctx
+}
+
+// NewParseCutPlugin creates a plugin sporting a cut.
+func NewParseCutPlugin() SubparserOp {
+	return ParseCut
+}
+
+// release releases memoized results, streamed content and data of the
+// tracer before the position.
+// Results of incremental memo tables are kept for reuse after edits.
+func (pd *ParseData) release(pos int) {
+	if pd.Source.stream != nil {
+		pd.ReleaseSource(pos)
+		return
+	}
+	pd.releaseTracer(pos)
+	if pd.Memo != nil && !pd.Memo.incremental {
+		pd.Memo.releaseBefore(pd.Source.base + pos)
+	}
+}
@@ -0,0 +1,123 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCut(t *testing.T) {
+	pFunc := NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "func"),
+		NewParseCutPlugin(),
+		NewParseLiteralPlugin(nil, "("),
+	}, nil)
+	pIdent := NewParseIdentPlugin(nil, "", "")
+	pAny := NewParseAnyPlugin([]SubparserOp{pFunc, pIdent}, nil)
+
+	runTests(t, pAny, []parseTestData{
+		{
+			givenParseData:   newData("committed match", 0, "func("),
+			expectedResult:   newResult(0, "func(", []interface{}{nil, nil, nil}, -1),
+			expectedSrcPos:   5,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("no commit", 0, "fun1"),
+			expectedResult:   newResult(0, "fun1", nil, -1),
+			expectedSrcPos:   4,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("hard error", 0, "func1"),
+			expectedResult:   newResult(0, "", nil, 4),
+			expectedSrcPos:   0,
+			expectedErrCount: 1,
+		},
+	})
+
+	pd, _ := pAny(newData("hard error", 0, "func1"), nil)
+	if !pd.Result.HardError {
+		t.Errorf("expected a hard error, got: %#v", pd.Result)
+	}
+	if _, err := pd.GetFeedback(); err == nil || strings.Contains(err.Error(), "Any subparser") {
+		t.Errorf("expected only the error of the committed alternative, got: %v", err)
+	}
+
+	pInner := NewParseAllPlugin([]SubparserOp{NewParseLiteralPlugin(nil, "a"), NewParseCutPlugin()}, nil)
+	pOuter := NewParseAllPlugin([]SubparserOp{pInner, NewParseLiteralPlugin(nil, "b")}, nil)
+	pd, _ = NewParseAnyPlugin([]SubparserOp{pOuter, pIdent}, nil)(newData("cut in nested ParseAll", 0, "ac"), nil)
+	if pd.Result.HasError() || pd.Result.Text != "ac" {
+		t.Errorf("expected a cut to be scoped to its ParseAll, got: %#v", pd.Result)
+	}
+}
+
+func TestParseCut_Propagation(t *testing.T) {
+	pAB := NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "a"),
+		NewParseCutPlugin(),
+		NewParseLiteralPlugin(nil, "b"),
+	}, nil)
+
+	specs := []struct {
+		name     string
+		p        SubparserOp
+		content  string
+		hard     bool
+		matched  bool
+		matchLen int
+	}{
+		{"Optional", NewParseOptionalPlugin(pAB, nil), "ac", true, false, 0},
+		{"Multi0", NewParseMulti0Plugin(pAB, nil), "ababac", true, false, 0},
+		{"Multi0 without commit", NewParseMulti0Plugin(pAB, nil), "ababc", false, true, 4},
+		{"Best", NewParseBestPlugin([]SubparserOp{pAB, NewParseLiteralPlugin(nil, "ac")}, nil), "ac", true, false, 0},
+		{"Not", NewParseNotPlugin(pAB, nil), "ac", false, true, 0},
+		{"And", NewParseAndPlugin(pAB, nil), "ac", false, false, 0},
+		{"Recover", NewParseRecoverPlugin(pAB, NewParseLiteralPlugin(nil, "c"), nil), "ac", false, true, 2},
+		{"Stream", NewParseStreamPlugin(pAB, nil), "abac", true, false, 0},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			pd, _ := spec.p(NewParseData(spec.name, spec.content), nil)
+			if pd.Result.HardError != spec.hard {
+				t.Errorf("expected hard error %t, got: %t", spec.hard, pd.Result.HardError)
+			}
+			if pd.Result.HasError() == spec.matched {
+				t.Errorf("expected match %t, got errors: %s", spec.matched, printErrors(pd.Result.Feedback))
+			}
+			if spec.matched && len(pd.Result.Text) != spec.matchLen {
+				t.Errorf("expected match of length %d, got: %q", spec.matchLen, pd.Result.Text)
+			}
+			if !spec.matched && pd.Source.pos != 0 {
+				t.Errorf("expected source position 0, got: %d", pd.Source.pos)
+			}
+		})
+	}
+}
+
+func TestParseCut_Release(t *testing.T) {
+	pA := NewParseMemoPlugin("a", NewParseLiteralPlugin(nil, "a"))
+	pAB := NewParseAllPlugin([]SubparserOp{pA, NewParseCutPlugin(), NewParseLiteralPlugin(nil, "b")}, nil)
+
+	pd := NewParseData("top level", "ab")
+	pd.Memo = NewMemoTable(0)
+	pd, _ = pAB(pd, nil)
+	if pd.Result.HasError() || pd.Memo.Len() != 0 {
+		t.Errorf("expected memoized results before the cut to be released, got %d", pd.Memo.Len())
+	}
+
+	pd = NewParseData("in alternative", "ab")
+	pd.Memo = NewMemoTable(0)
+	pd, _ = NewParseAnyPlugin([]SubparserOp{pAB}, nil)(pd, nil)
+	if pd.Result.HasError() || pd.Memo.Len() != 1 {
+		t.Errorf("expected memoized results to be kept, got %d", pd.Memo.Len())
+	}
+
+	pd = NewStreamParseData("stream", strings.NewReader("a\nb"), 1)
+	pAB = NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "a\n"),
+		NewParseCutPlugin(),
+		NewParseLiteralPlugin(nil, "b"),
+	}, nil)
+	pd, _ = pAB(pd, nil)
+	if pd.Result.HasError() || pd.Source.offset != 2 {
+		t.Errorf("expected the streamed content before the cut to be released, got offset %d", pd.Source.offset)
+	}
+}
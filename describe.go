@@ -0,0 +1,421 @@
+package gparselib
+
+import (
+	"math"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// ParserKind is just an enumeration of the kinds of parsers of this package.
+type ParserKind int
+
+// Enumeration of the kinds of parsers.
+const (
+	KindCustom = ParserKind(iota) // a parser that isn't from this package
+	KindLiteral
+	KindIdent
+	KindNatural
+	KindEOF
+	KindSpace
+	KindRegexp
+	KindLineComment
+	KindBlockComment
+	KindGoodRunes
+	KindMulti
+	KindOptional
+	KindNot
+	KindAnd
+	KindAll
+	KindAny
+	KindBest
+	KindCut
+	KindLabel
+	KindRecover
+	KindStream
+	KindMemo
+	KindLeftRec
+	KindRule // a named rule with its subparser (see ParseRule)
+	KindRef  // a reference to a rule of a grammar (see Grammar.Ref)
+)
+
+// descRecursion is the configuration of KindCustom descriptors of recursive
+// parsers that aren't rules.
+const descRecursion = "recursion"
+
+var parserKindNames = [...]string{
+	KindCustom:       "custom",
+	KindLiteral:      "literal",
+	KindIdent:        "identifier",
+	KindNatural:      "natural",
+	KindEOF:          "eof",
+	KindSpace:        "space",
+	KindRegexp:       "regexp",
+	KindLineComment:  "line comment",
+	KindBlockComment: "block comment",
+	KindGoodRunes:    "good runes",
+	KindMulti:        "multi",
+	KindOptional:     "optional",
+	KindNot:          "not",
+	KindAnd:          "and",
+	KindAll:          "all",
+	KindAny:          "any",
+	KindBest:         "best",
+	KindCut:          "cut",
+	KindLabel:        "label",
+	KindRecover:      "recover",
+	KindStream:       "stream",
+	KindMemo:         "memo",
+	KindLeftRec:      "left recursion",
+	KindRule:         "rule",
+	KindRef:          "reference",
+}
+
+func (k ParserKind) String() string {
+	if k < 0 || int(k) >= len(parserKindNames) {
+		return "unknown"
+	}
+	return parserKindNames[k]
+}
+
+// Descriptor describes a parser with its configuration and subparsers, so
+// grammars can be inspected after they are built (e.g.: for documentation).
+type Descriptor struct {
+	Kind     ParserKind
+	Name     string   // name of the rule (rule, reference, memo, left recursion) or the label
+	Config   []string // further configuration as text (e.g.: the literal)
+	Min, Max int      // minimum and maximum number of matches (only multi)
+	Children []*Descriptor
+}
+
+// String returns the described parser in PEG-like notation.
+// Rules (KindRule) are written as definitions (`rule <- a b / c*`).
+// In addition to PEG the notation contains longest matches (`a | b`), cuts
+// (`~`), counted repetitions (`a{2,5}`) and names of special parsers in
+// angle brackets (e.g.: `<identifier>`).
+// Labels, memoization, left recursion and recovery aren't shown.
+func (d *Descriptor) String() string {
+	b := &strings.Builder{}
+	if d.Kind == KindRule {
+		b.WriteString(d.Name + " <- ")
+		if len(d.Children) == 0 {
+			b.WriteString("<undefined>")
+			return b.String()
+		}
+		d = d.Children[0]
+	}
+	writeDescriptor(b, d, 0)
+	return b.String()
+}
+
+// String returns all defined rules of the grammar in PEG-like notation (see
+// Descriptor.String) with one rule per line.
+// The start rule comes first and the others follow in order of their first
+// appearance.
+func (g *Grammar) String() string {
+	descs := g.Describe()
+	for i, d := range descs {
+		if d.Name == g.start {
+			copy(descs[1:i+1], descs[:i])
+			descs[0] = d
+			break
+		}
+	}
+	width := 0
+	for _, d := range descs {
+		width = max(width, len(d.Name))
+	}
+	b := &strings.Builder{}
+	for _, d := range descs {
+		if len(d.Children) == 0 {
+			continue
+		}
+		b.WriteString(d.Name + strings.Repeat(" ", width-len(d.Name)) + " <- ")
+		writeDescriptor(b, d.Children[0], 0)
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
+
+// writeDescriptor writes the described parser in PEG-like notation.
+// Parentheses are written like in writePEG.
+func writeDescriptor(b *strings.Builder, d *Descriptor, prec int) {
+	open := func(p int) {
+		if prec > p {
+			b.WriteRune('(')
+		}
+	}
+	closing := func(p int) {
+		if prec > p {
+			b.WriteRune(')')
+		}
+	}
+	list := func(p int, sep string) {
+		open(p)
+		for i, c := range d.Children {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			writeDescriptor(b, c, p+1)
+		}
+		closing(p)
+	}
+	suffix := func(s string) {
+		open(3)
+		writeDescriptor(b, d.Children[0], 4)
+		b.WriteString(s)
+		closing(3)
+	}
+	prefix := func(s string) {
+		open(2)
+		b.WriteString(s)
+		writeDescriptor(b, d.Children[0], 3)
+		closing(2)
+	}
+
+	switch d.Kind {
+	case KindLiteral:
+		b.WriteString(quotePEG(d.Config[0]))
+	case KindRegexp:
+		b.WriteString(regexpPEG(d.Config[0]))
+	case KindEOF:
+		b.WriteString("!.")
+	case KindRef:
+		b.WriteString(d.Name)
+	case KindAll:
+		list(1, " ")
+	case KindAny:
+		list(0, " / ")
+	case KindBest:
+		list(0, " | ")
+	case KindMulti:
+		suffix(multiPEG(d.Min, d.Max))
+	case KindOptional:
+		suffix("?")
+	case KindStream:
+		suffix("*")
+	case KindNot:
+		prefix("!")
+	case KindAnd:
+		prefix("&")
+	case KindCut:
+		b.WriteRune('~')
+	case KindLabel, KindRecover, KindMemo, KindLeftRec, KindRule:
+		writeDescriptor(b, d.Children[0], prec)
+	case KindCustom:
+		switch {
+		case len(d.Children) > 0:
+			list(1, " ")
+		case len(d.Config) > 0:
+			b.WriteString("<" + d.Config[0] + ">")
+		default:
+			b.WriteString("<custom>")
+		}
+	case KindNatural:
+		b.WriteString("<natural")
+		if d.Config[0] != "10" {
+			b.WriteString(" " + d.Config[0])
+		}
+		b.WriteRune('>')
+	case KindSpace:
+		b.WriteString("<space")
+		if d.Config[0] == "true" {
+			b.WriteString(" eol")
+		}
+		b.WriteRune('>')
+	default: // the other simple parsers
+		b.WriteString("<" + d.Kind.String())
+		for _, c := range d.Config {
+			if c != "" {
+				b.WriteString(" " + quotePEG(c))
+			}
+		}
+		b.WriteRune('>')
+	}
+}
+
+// regexpPEG returns the regular expression in PEG notation if possible
+// (character classes and any character) or enclosed in slashes.
+func regexpPEG(re string) string {
+	parsed, err := syntax.Parse(re, syntax.Perl)
+	if err == nil {
+		switch parsed.Op {
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			return "."
+		case syntax.OpCharClass, syntax.OpLiteral:
+			if strings.HasPrefix(re, "[") && (parsed.Op == syntax.OpCharClass || len(parsed.Rune) == 1) {
+				return re
+			}
+		}
+	}
+	return "/" + re + "/"
+}
+
+// multiPEG returns the suffix for the number of matches.
+func multiPEG(cfgMin, cfgMax int) string {
+	switch {
+	case cfgMin == 0 && cfgMax == 1:
+		return "?"
+	case cfgMax == math.MaxInt32 && cfgMin <= 0:
+		return "*"
+	case cfgMax == math.MaxInt32 && cfgMin == 1:
+		return "+"
+	case cfgMax == math.MaxInt32:
+		return "{" + strconv.Itoa(cfgMin) + ",}"
+	case cfgMin == cfgMax:
+		return "{" + strconv.Itoa(cfgMin) + "}"
+	}
+	return "{" + strconv.Itoa(cfgMin) + "," + strconv.Itoa(cfgMax) + "}"
+}
+
+// maxDescribeDepth limits the nesting of descriptors, so recursive parsers
+// that aren't rules can be described, too.
+const maxDescribeDepth = 64
+
+// describeAbort is panicked to stop describing a parser that nests too
+// deeply; all other panics aren't recovered.
+type describeAbort struct{}
+
+// describer collects the descriptors of the parsers called while describing.
+type describer struct {
+	descs  []*Descriptor     // descriptors of the parsers called on the current level
+	active map[namedKey]bool // named parsers that are described right now
+	lazy   map[interface{}]bool
+	depth  int
+}
+
+// namedKey identifies a named parser (rule, memo or left recursion).
+// Named parsers of different kinds can share their name (e.g.: a rule and
+// the memo wrapping its body).
+type namedKey struct {
+	kind ParserKind
+	name string
+}
+
+func newDescriber() *describer {
+	return &describer{active: make(map[namedKey]bool), lazy: make(map[interface{}]bool)}
+}
+
+// Describe returns the descriptor of the parser.
+// The parser isn't called for parsing but all parsers of this package called
+// by it just describe themselves.
+// Other parsers are described as KindCustom.
+// They are called with a nil context and all parsers of this package fail in
+// describe mode, so they should only call their subparsers and mustn't have
+// other side effects (e.g.: semantics are only called for matches).
+// Recursion is described with references (KindRef) to named rules (see
+// ParseRule, ParseMemo and ParseLeftRec).
+func Describe(pluginSubparser SubparserOp) *Descriptor {
+	pd := &ParseData{describer: newDescriber()}
+	return pd.describer.describe(pd, pluginSubparser)
+}
+
+// Describe returns the descriptors of all rules of the grammar in order of
+// their first appearance.
+// The descriptors are of kind KindRule with the subparser of the rule as
+// only child (or no child if the rule isn't defined).
+func (g *Grammar) Describe() []*Descriptor {
+	descs := make([]*Descriptor, 0, len(g.order))
+	for _, name := range g.order {
+		d := &Descriptor{Kind: KindRule, Name: name}
+		if r := g.rules[name]; r.subparser != nil {
+			pd := &ParseData{describer: newDescriber()}
+			d.Children = []*Descriptor{pd.describer.describe(pd, r.subparser)}
+		}
+		descs = append(descs, d)
+	}
+	return descs
+}
+
+// describe calls the parser in describe mode and returns its descriptor.
+func (dr *describer) describe(pd *ParseData, pluginSubparser SubparserOp) (d *Descriptor) {
+	if pluginSubparser == nil {
+		return &Descriptor{Kind: KindCustom}
+	}
+	if dr.depth >= maxDescribeDepth {
+		panic(describeAbort{}) // the calling parser is described as recursion
+	}
+	parentDescs := dr.descs
+	dr.descs = nil
+	dr.depth++
+	defer func() {
+		dr.depth--
+		descs := dr.descs
+		dr.descs = parentDescs
+		if r := recover(); r != nil {
+			if _, ok := r.(describeAbort); !ok {
+				panic(r)
+			}
+			d = &Descriptor{Kind: KindCustom, Config: []string{descRecursion}}
+			return
+		}
+		switch len(descs) {
+		case 0:
+			d = &Descriptor{Kind: KindCustom}
+		case 1:
+			d = descs[0]
+		default:
+			d = &Descriptor{Kind: KindCustom, Children: descs}
+		}
+	}()
+	pluginSubparser(pd, nil)
+	return nil
+}
+
+// describeParser adds the descriptor with its described subparsers to the
+// current level.
+// The result is an error, so parsers that aren't from this package don't
+// call semantics.
+func describeParser(
+	pd *ParseData, ctx interface{},
+	d *Descriptor, pluginSubparsers ...SubparserOp,
+) (*ParseData, interface{}) {
+	dr := pd.describer
+	for _, op := range pluginSubparsers {
+		d.Children = append(d.Children, dr.describe(pd, op))
+	}
+	dr.descs = append(dr.descs, d)
+	pd.Result = &ParseResult{
+		Pos:    pd.Source.pos,
+		ErrPos: pd.Source.pos,
+		Feedback: []*FeedbackItem{
+			{Pos: pd.Source.pos, Kind: FeedbackError, Msg: &ParseError{Msg: "The parser is only described"}},
+		},
+	}
+	return pd, ctx
+}
+
+// describeNamed works like describeParser for parsers of named rules.
+// If the parser of the same kind and name is described already, a reference
+// to it is added instead.
+func describeNamed(
+	pd *ParseData, ctx interface{},
+	d *Descriptor, pluginSubparser SubparserOp,
+) (*ParseData, interface{}) {
+	dr := pd.describer
+	key := namedKey{d.Kind, d.Name}
+	if dr.active[key] {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindRef, Name: d.Name})
+	}
+	dr.active[key] = true
+	pd, ctx = describeParser(pd, ctx, d, pluginSubparser)
+	delete(dr.active, key)
+	return pd, ctx
+}
+
+// describeLazy describes the lazily created parser in place unless it is
+// described already (recursion).
+// The key has to identify the parser.
+func describeLazy(
+	pd *ParseData, ctx interface{},
+	key interface{}, pluginSubparser SubparserOp,
+) (*ParseData, interface{}) {
+	dr := pd.describer
+	if dr.lazy[key] {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindCustom, Config: []string{descRecursion}})
+	}
+	dr.lazy[key] = true
+	pd, ctx = pluginSubparser(pd, ctx)
+	delete(dr.lazy, key)
+	return pd, ctx
+}
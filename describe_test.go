@@ -0,0 +1,294 @@
+package gparselib
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	pA := NewParseLiteralPlugin(nil, "a")
+	dA := &Descriptor{Kind: KindLiteral, Config: []string{"a"}}
+	pNatural, err := NewParseNaturalPlugin(nil, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pRegexp, err := NewParseRegexpPlugin(nil, `[a-z]+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pBlock, err := NewParseBlockCommentPlugin(nil, "/*", "*/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var pList SubparserOp
+	pList = NewParseLeftRecPlugin("list", NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{
+			func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) { return pList(pd, ctx) },
+			pA,
+		}, nil),
+		pA,
+	}, nil))
+	var nested Parser[string]
+	nested = Lazy(func() Parser[string] {
+		return Choice(Map(Seq2(Literal("("), nested), func(p Pair[string, string]) string { return p.Second }), Literal("x"))
+	})
+
+	specs := []struct {
+		name     string
+		givenOp  SubparserOp
+		expected *Descriptor
+	}{
+		{
+			name:     "literal",
+			givenOp:  pA,
+			expected: dA,
+		}, {
+			name: "simple parsers",
+			givenOp: NewParseAllPlugin([]SubparserOp{
+				NewParseIdentPlugin(nil, "_", "-"),
+				pNatural,
+				pRegexp,
+				NewParseSpacePlugin(nil, true),
+				pBlock,
+				NewParseEOFPlugin(nil),
+			}, nil),
+			expected: &Descriptor{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindIdent, Config: []string{"_", "-"}},
+				{Kind: KindNatural, Config: []string{"16"}},
+				{Kind: KindRegexp, Config: []string{"[a-z]+"}},
+				{Kind: KindSpace, Config: []string{"true"}},
+				{Kind: KindBlockComment, Config: []string{"/*", "*/"}},
+				{Kind: KindEOF},
+			}},
+		}, {
+			name: "combinators",
+			givenOp: NewParseAnyPlugin([]SubparserOp{
+				NewParseMulti1Plugin(pA, nil),
+				NewParseOptionalPlugin(NewParseNotPlugin(pA, nil), nil),
+				NewParseLabelPlugin("letter a", NewParseAllPlugin([]SubparserOp{ParseCut, pA}, nil)),
+			}, nil),
+			expected: &Descriptor{Kind: KindAny, Children: []*Descriptor{
+				{Kind: KindMulti, Min: 1, Max: math.MaxInt32, Children: []*Descriptor{dA}},
+				{Kind: KindOptional, Children: []*Descriptor{{Kind: KindNot, Children: []*Descriptor{dA}}}},
+				{Kind: KindLabel, Name: "letter a", Children: []*Descriptor{
+					{Kind: KindAll, Children: []*Descriptor{{Kind: KindCut}, dA}},
+				}},
+			}},
+		}, {
+			name:    "left recursion",
+			givenOp: pList,
+			expected: &Descriptor{Kind: KindLeftRec, Name: "list", Children: []*Descriptor{
+				{Kind: KindAny, Children: []*Descriptor{
+					{Kind: KindAll, Children: []*Descriptor{{Kind: KindRef, Name: "list"}, dA}},
+					dA,
+				}},
+			}},
+		}, {
+			name:    "lazy recursion",
+			givenOp: nested.Op(),
+			expected: &Descriptor{Kind: KindAny, Children: []*Descriptor{
+				{Kind: KindAll, Children: []*Descriptor{
+					{Kind: KindLiteral, Config: []string{"("}},
+					{Kind: KindCustom, Config: []string{descRecursion}},
+				}},
+				{Kind: KindLiteral, Config: []string{"x"}},
+			}},
+		}, {
+			name: "custom parsers",
+			givenOp: NewParseAllPlugin([]SubparserOp{
+				func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) { return pd, ctx },
+				func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+					pd, ctx = pA(pd, ctx)
+					return pA(pd, ctx)
+				},
+			}, nil),
+			expected: &Descriptor{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindCustom},
+				{Kind: KindCustom, Children: []*Descriptor{dA, dA}},
+			}},
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			got := Describe(spec.givenOp)
+			if !reflect.DeepEqual(got, spec.expected) {
+				t.Errorf("expected descriptor %#v, got: %#v", spec.expected, got)
+			}
+		})
+	}
+}
+
+func TestDescribe_CustomRecursion(t *testing.T) {
+	var p SubparserOp
+	p = NewParseOptionalPlugin(func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return p(pd, ctx)
+	}, nil)
+
+	depth := 0
+	d := Describe(p)
+	for d.Kind == KindOptional {
+		depth++
+		d = d.Children[0]
+	}
+	if depth != maxDescribeDepth-1 || d.Kind != KindCustom || len(d.Config) != 1 || d.Config[0] != descRecursion {
+		t.Errorf("expected recursion after %d optional parsers, got %d and: %#v", maxDescribeDepth-1, depth, d)
+	}
+}
+
+func TestDescribe_Panic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("expected the panic of the custom parser, got: %v", r)
+		}
+	}()
+	Describe(NewParseAllPlugin([]SubparserOp{
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) { panic("boom") },
+	}, nil))
+}
+
+func TestGrammar_Describe(t *testing.T) {
+	g := NewGrammar()
+	g.Define("list", NewParseAllPlugin([]SubparserOp{
+		g.Ref("item"),
+		NewParseMulti0Plugin(NewParseAllPlugin([]SubparserOp{NewParseLiteralPlugin(nil, ","), g.Ref("item")}, nil), nil),
+	}, nil))
+	g.Define("item", NewParseAnyPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "x"),
+		NewParseAllPlugin([]SubparserOp{NewParseLiteralPlugin(nil, "["), g.Ref("list"), NewParseLiteralPlugin(nil, "]")}, nil),
+	}, nil))
+	g.Ref("missing")
+
+	got := g.Describe() // "item" is referenced before "list" is defined
+	lit := func(s string) *Descriptor { return &Descriptor{Kind: KindLiteral, Config: []string{s}} }
+	expected := []*Descriptor{
+		{Kind: KindRule, Name: "item", Children: []*Descriptor{
+			{Kind: KindAny, Children: []*Descriptor{
+				lit("x"),
+				{Kind: KindAll, Children: []*Descriptor{lit("["), {Kind: KindRef, Name: "list"}, lit("]")}},
+			}},
+		}},
+		{Kind: KindRule, Name: "list", Children: []*Descriptor{
+			{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindRef, Name: "item"},
+				{Kind: KindMulti, Min: 0, Max: math.MaxInt32, Children: []*Descriptor{
+					{Kind: KindAll, Children: []*Descriptor{lit(","), {Kind: KindRef, Name: "item"}}},
+				}},
+			}},
+		}},
+		{Kind: KindRule, Name: "missing"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected descriptors %#v, got: %#v", expected, got)
+	}
+
+	pd, _ := g.Start()(NewParseData("test", "[x,x],x"), nil)
+	if pd.Result.HasError() || pd.Source.pos != 7 {
+		t.Errorf("expected parser to work after describing it, got: %s", printErrors(pd.Result.Feedback))
+	}
+}
+
+func TestDescriptor_String(t *testing.T) {
+	lit := func(s string) *Descriptor { return &Descriptor{Kind: KindLiteral, Config: []string{s}} }
+	specs := []struct {
+		name     string
+		given    *Descriptor
+		expected string
+	}{
+		{
+			name:     "literal",
+			given:    lit("it's"),
+			expected: `'it\'s'`,
+		}, {
+			name: "regexps",
+			given: &Descriptor{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindRegexp, Config: []string{"[a-z]"}},
+				{Kind: KindRegexp, Config: []string{"[a]"}},
+				{Kind: KindRegexp, Config: []string{"(?s)."}},
+				{Kind: KindRegexp, Config: []string{"[a-z]+"}},
+			}},
+			expected: `[a-z] [a] . /[a-z]+/`,
+		}, {
+			name: "simple parsers",
+			given: &Descriptor{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindIdent, Config: []string{"", ""}},
+				{Kind: KindIdent, Config: []string{"_", "-"}},
+				{Kind: KindNatural, Config: []string{"10"}},
+				{Kind: KindNatural, Config: []string{"16"}},
+				{Kind: KindSpace, Config: []string{"true"}},
+				{Kind: KindSpace, Config: []string{"false"}},
+				{Kind: KindBlockComment, Config: []string{"/*", "*/"}},
+				{Kind: KindEOF},
+			}},
+			expected: `<identifier> <identifier '_' '-'> <natural> <natural 16> <space eol> <space> <block comment '/*' '*/'> !.`,
+		}, {
+			name: "precedence",
+			given: &Descriptor{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindAny, Children: []*Descriptor{lit("a"), {Kind: KindAll, Children: []*Descriptor{lit("b"), {Kind: KindCut}, lit("c")}}}},
+				{Kind: KindNot, Children: []*Descriptor{{Kind: KindOptional, Children: []*Descriptor{lit("d")}}}},
+				{Kind: KindMulti, Min: 1, Max: math.MaxInt32, Children: []*Descriptor{{Kind: KindAnd, Children: []*Descriptor{lit("e")}}}},
+				{Kind: KindBest, Children: []*Descriptor{lit("f"), lit("g")}},
+			}},
+			expected: `('a' / 'b' ~ 'c') !'d'? (&'e')+ ('f' | 'g')`,
+		}, {
+			name: "multis",
+			given: &Descriptor{Kind: KindAll, Children: []*Descriptor{
+				{Kind: KindMulti, Min: 0, Max: math.MaxInt32, Children: []*Descriptor{lit("a")}},
+				{Kind: KindMulti, Min: 0, Max: 1, Children: []*Descriptor{lit("b")}},
+				{Kind: KindMulti, Min: 2, Max: math.MaxInt32, Children: []*Descriptor{lit("c")}},
+				{Kind: KindMulti, Min: 3, Max: 3, Children: []*Descriptor{lit("d")}},
+				{Kind: KindMulti, Min: 2, Max: 5, Children: []*Descriptor{lit("e")}},
+			}},
+			expected: `'a'* 'b'? 'c'{2,} 'd'{3} 'e'{2,5}`,
+		}, {
+			name: "wrappers",
+			given: &Descriptor{Kind: KindRule, Name: "r", Children: []*Descriptor{
+				{Kind: KindMemo, Name: "r", Children: []*Descriptor{
+					{Kind: KindAny, Children: []*Descriptor{
+						{Kind: KindLabel, Name: "x", Children: []*Descriptor{{Kind: KindAny, Children: []*Descriptor{lit("a"), lit("b")}}}},
+						{Kind: KindRef, Name: "s"},
+						{Kind: KindCustom},
+						{Kind: KindCustom, Config: []string{descRecursion}},
+					}},
+				}},
+			}},
+			expected: `r <- ('a' / 'b') / s / <custom> / <recursion>`,
+		}, {
+			name:     "undefined rule",
+			given:    &Descriptor{Kind: KindRule, Name: "r"},
+			expected: `r <- <undefined>`,
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			if got := spec.given.String(); got != spec.expected {
+				t.Errorf("expected %q, got: %q", spec.expected, got)
+			}
+		})
+	}
+}
+
+func TestGrammar_String(t *testing.T) {
+	g := loadCalcGrammar(t)
+	expected := `calc    <- space expr !.
+space   <- [ \t\n]*
+expr    <- expr '+' space term / expr '-' space term / term
+term    <- number / '(' space expr ')' space
+number  <- [0-9]+ space
+keyword <- ('if' / 'else') ![a-z]
+any     <- &'a' . .?
+`
+	got := g.String()
+	if got != expected {
+		t.Fatalf("expected grammar:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// the notation can be loaded again (without semantic actions)
+	g2, _, err := LoadGrammar("calc2.peg", got, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got2 := g2.String(); got2 != got {
+		t.Errorf("expected reloaded grammar:\n%s\ngot:\n%s", got, got2)
+	}
+}
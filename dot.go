@@ -0,0 +1,68 @@
+package gparselib
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the graph of the rules of the grammar and their references
+// in the DOT language of Graphviz (see https://graphviz.org).
+// The start rule has a bold border and rules that are never defined are
+// dashed.
+// Every recursion cycle is a red cluster with red references inside and
+// left recursive references are bold and labeled "left".
+func (g *Grammar) WriteDOT(w io.Writer, name string) error {
+	rg := newRuleGraph(g)
+	inCycle := make(map[string]int, len(rg.descs)) // rule -> number of its cycle + 1
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %s {\n", dotQuote(name))
+	b.WriteString("\tnode [shape=box];\n")
+	for i, cycle := range rg.cycles() {
+		fmt.Fprintf(b, "\tsubgraph cluster_%d {\n", i)
+		b.WriteString("\t\tlabel=\"recursion\";\n\t\tcolor=red;\n\t\tfontcolor=red;\n")
+		for _, rule := range cycle {
+			inCycle[rule] = i + 1
+			fmt.Fprintf(b, "\t\t%s;\n", dotQuote(rule))
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, d := range rg.descs {
+		var attrs []string
+		if d.Name == g.start {
+			attrs = append(attrs, "penwidth=2")
+		}
+		if len(d.Children) == 0 {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) > 0 || inCycle[d.Name] == 0 {
+			fmt.Fprintf(b, "\t%s%s;\n", dotQuote(d.Name), dotAttributes(attrs))
+		}
+	}
+	for _, d := range rg.descs {
+		for _, ref := range rg.refs[d.Name] {
+			var attrs []string
+			if inCycle[d.Name] != 0 && inCycle[d.Name] == inCycle[ref] {
+				attrs = append(attrs, "color=red")
+			}
+			if rg.leftRecursive(d.Name, ref) {
+				attrs = append(attrs, "style=bold", `label="left"`, "fontcolor=red")
+			}
+			fmt.Fprintf(b, "\t%s -> %s%s;\n", dotQuote(d.Name), dotQuote(ref), dotAttributes(attrs))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotAttributes(attrs []string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(attrs, ", ") + "]"
+}
+
+func dotQuote(id string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(id) + `"`
+}
@@ -0,0 +1,91 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrammar_WriteDOT(t *testing.T) {
+	lit := func(s string) SubparserOp { return NewParseLiteralPlugin(nil, s) }
+	mutual := NewGrammar()
+	mutual.Define("a", NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{mutual.Ref("b"), lit("x")}, nil),
+		lit("y"),
+	}, nil))
+	mutual.Define("b", NewParseAllPlugin([]SubparserOp{
+		NewParseOptionalPlugin(lit("-"), nil),
+		mutual.Ref("a"),
+		mutual.Ref(`a "quoted"`),
+		mutual.Ref("c"),
+	}, nil))
+	mutual.Define(`a "quoted"`, NewParseAllPlugin([]SubparserOp{lit("("), mutual.Ref("b"), lit(")")}, nil))
+
+	specs := []struct {
+		name         string
+		givenGrammar *Grammar
+		expectedDOT  string
+	}{
+		{
+			name:         "calc",
+			givenGrammar: loadCalcGrammar(t),
+			expectedDOT: `digraph "test" {
+	node [shape=box];
+	subgraph cluster_0 {
+		label="recursion";
+		color=red;
+		fontcolor=red;
+		"expr";
+		"term";
+	}
+	"space";
+	"calc" [penwidth=2];
+	"number";
+	"keyword";
+	"any";
+	"expr" -> "expr" [color=red, style=bold, label="left", fontcolor=red];
+	"expr" -> "space";
+	"expr" -> "term" [color=red];
+	"calc" -> "space";
+	"calc" -> "expr";
+	"term" -> "number";
+	"term" -> "space";
+	"term" -> "expr" [color=red];
+	"number" -> "space";
+}
+`,
+		}, {
+			name:         "mutual left recursion",
+			givenGrammar: mutual,
+			expectedDOT: `digraph "test" {
+	node [shape=box];
+	subgraph cluster_0 {
+		label="recursion";
+		color=red;
+		fontcolor=red;
+		"b";
+		"a";
+		"a \"quoted\"";
+	}
+	"a" [penwidth=2];
+	"c" [style=dashed];
+	"b" -> "a" [color=red, style=bold, label="left", fontcolor=red];
+	"b" -> "a \"quoted\"" [color=red];
+	"b" -> "c";
+	"a" -> "b" [color=red, style=bold, label="left", fontcolor=red];
+	"a \"quoted\"" -> "b" [color=red];
+}
+`,
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			b := &strings.Builder{}
+			if err := spec.givenGrammar.WriteDOT(b, "test"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := b.String(); got != spec.expectedDOT {
+				t.Errorf("expected DOT:\n%s\ngot:\n%s", spec.expectedDOT, got)
+			}
+		})
+	}
+}
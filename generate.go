@@ -0,0 +1,297 @@
+package gparselib
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GenerateGo generates the Go source code of a parser for a grammar written
+// in PEG notation (see LoadGrammar).
+// Every rule is a method of the generated parser type (e.g.: parseExpr for
+// the rule 'expr') and all expressions that are subparsers are methods named
+// after their rule (e.g.: exprSeq1).
+// The methods call the parsers of this package directly with other methods
+// as subparsers instead of closures built with the NewParse*Plugin
+// functions, so the parser can be read and debugged like hand written code.
+// It gives the same results as the grammar loaded with LoadGrammar.
+// It is about as fast because the parsers of this package still call their
+// subparsers through function values (see BenchmarkGeneratedParser).
+// Its constructor gets the semantic actions; the start rule is used by its
+// Parse method and all rules are available by name with its Rule method.
+// Problems in the grammar are reported just like LoadGrammar does.
+func GenerateGo(w io.Writer, name, text, pkg, typ string) (string, error) {
+	pd, rules := parsePEG(name, text)
+	if rules == nil {
+		return pd.GetFeedback()
+	}
+	b := &pegBuilder{pd: pd, g: NewGrammar(), ignoreActions: true}
+	b.buildRules(rules)
+	fb, err := pd.GetFeedback()
+	if err != nil {
+		return fb, err
+	}
+
+	gen := newGoGenerator(name, pkg, typ, rules, b.leftRec)
+	src, err := format.Source(gen.generate())
+	if err != nil {
+		return fb, err
+	}
+	_, err = w.Write(src)
+	return fb, err
+}
+
+// goGenerator generates Go source code for the rules of a PEG grammar.
+// Every rule is a method of the generated parser type that is called
+// directly by the other rules.
+// Expressions that are subparsers of other expressions get their own methods
+// named after their rule and composite expressions get slices of their
+// subparsers.
+type goGenerator struct {
+	name    string
+	pkg     string
+	typ     string
+	rules   []*pegRule
+	leftRec map[string]bool
+	idents  map[string]bool   // all identifiers used in the parser type
+	parsers map[string]string // method of every rule
+	methods []goMethod
+	subs    []goSubs
+	actions []string
+	regexps []string
+	rule    string // name of the rule whose expressions are generated
+	n       int    // number of expression methods of the rule
+}
+
+// goMethod is a generated method of the parser type.
+type goMethod struct {
+	name    string
+	comment string
+	body    string
+}
+
+// goSubs is a generated field holding the subparsers of a composite
+// expression.
+type goSubs struct {
+	name string
+	ops  []string
+}
+
+func newGoGenerator(name, pkg, typ string, rules []*pegRule, leftRec map[string]bool) *goGenerator {
+	return &goGenerator{
+		name:    name,
+		pkg:     pkg,
+		typ:     typ,
+		rules:   rules,
+		leftRec: leftRec,
+		idents:  map[string]bool{"Parse": true, "Rule": true, "actions": true, "regexps": true},
+		parsers: make(map[string]string, len(rules)),
+	}
+}
+
+func (gen *goGenerator) generate() []byte {
+	for _, r := range gen.rules {
+		gen.parsers[r.name] = gen.ident("parse" + strings.ToUpper(r.name[:1]) + r.name[1:])
+	}
+	for _, r := range gen.rules {
+		gen.rule, gen.n = r.name, 0
+		rule := gen.newMethod(gen.parsers[r.name], r.name+" <- "+r.expr.String())
+		var wrapper int
+		if gen.leftRec[r.name] {
+			wrapper = gen.newMethod(gen.ident(r.name+"LeftRec"), "left recursive rule "+r.name)
+			gen.methods[wrapper].body = fmt.Sprintf("gparselib.ParseLeftRec(pd, ctx, %s, %q)", gen.expr(r.expr, "Body"), r.name)
+		} else {
+			wrapper = gen.newMethod(gen.ident(r.name+"Memo"), "memoized rule "+r.name)
+			gen.methods[wrapper].body = fmt.Sprintf("gparselib.ParseMemo(pd, ctx, %s, %q)", gen.expr(r.expr, "Body"), r.name)
+		}
+		gen.methods[rule].body = fmt.Sprintf("gparselib.ParseRule(pd, ctx, p.%s, %q)", gen.methods[wrapper].name, r.name)
+	}
+
+	b := &strings.Builder{}
+	gen.writeHeader(b)
+	gen.writeConstructor(b)
+	gen.writeMethods(b)
+	return []byte(b.String())
+}
+
+// ident returns a unique identifier starting with the given one.
+func (gen *goGenerator) ident(name string) string {
+	ident := name
+	for i := 2; gen.idents[ident]; i++ {
+		ident = name + strconv.Itoa(i)
+	}
+	gen.idents[ident] = true
+	return ident
+}
+
+func (gen *goGenerator) newMethod(name, comment string) int {
+	gen.methods = append(gen.methods, goMethod{name: name, comment: strings.ReplaceAll(comment, "\n", `\n`)})
+	return len(gen.methods) - 1
+}
+
+// newSubs generates the subparsers of the composite expression of the method
+// and returns the name of the field holding them.
+func (gen *goGenerator) newSubs(method string, es []*pegExpr) string {
+	subs := goSubs{name: gen.ident(method + "Subs"), ops: make([]string, len(es))}
+	for i, e := range es {
+		subs.ops[i] = gen.expr(e, "")
+	}
+	gen.subs = append(gen.subs, subs)
+	return "p." + subs.name
+}
+
+func (gen *goGenerator) newRegexp(re string) int {
+	for i, r := range gen.regexps {
+		if r == re {
+			return i
+		}
+	}
+	gen.regexps = append(gen.regexps, re)
+	return len(gen.regexps) - 1
+}
+
+func (gen *goGenerator) action(name string) string {
+	if name == "" {
+		return "nil"
+	}
+	for i, a := range gen.actions {
+		if a == name {
+			return fmt.Sprintf("p.actions[%d]", i)
+		}
+	}
+	gen.actions = append(gen.actions, name)
+	return fmt.Sprintf("p.actions[%d]", len(gen.actions)-1)
+}
+
+// pegKindNames are used for the names of the generated methods.
+var pegKindNames = map[pegKind]string{
+	pegLiteral:  "Literal",
+	pegClass:    "Class",
+	pegAnyChar:  "AnyChar",
+	pegSeq:      "Seq",
+	pegChoice:   "Choice",
+	pegMulti0:   "ZeroOrMore",
+	pegMulti1:   "OneOrMore",
+	pegOptional: "Optional",
+	pegNot:      "Not",
+	pegAnd:      "And",
+}
+
+// expr generates the method for the expression and returns it as a
+// subparser.
+// The method is named after the current rule and the suffix or the kind of
+// the expression if the suffix is empty.
+// References to rules are the methods of the rules.
+func (gen *goGenerator) expr(e *pegExpr, suffix string) string {
+	if e.kind == pegRef {
+		return "p." + gen.parsers[e.text]
+	}
+	if suffix == "" {
+		gen.n++
+		suffix = pegKindNames[e.kind] + strconv.Itoa(gen.n)
+	}
+	m := gen.newMethod(gen.ident(gen.rule+suffix), e.String())
+	name := gen.methods[m].name
+	var body string
+	switch e.kind {
+	case pegLiteral:
+		body = fmt.Sprintf("gparselib.ParseLiteral(pd, ctx, nil, %s)", strconv.Quote(e.text))
+	case pegClass:
+		body = fmt.Sprintf("p.regexps[%d].ParseRegexp(pd, ctx, nil)", gen.newRegexp(e.text))
+	case pegAnyChar:
+		body = fmt.Sprintf("p.regexps[%d].ParseRegexp(pd, ctx, nil)", gen.newRegexp(`(?s).`))
+	case pegSeq:
+		body = fmt.Sprintf("gparselib.ParseAll(pd, ctx, %s, %s)", gen.newSubs(name, e.children), gen.action(e.action))
+	case pegChoice:
+		body = fmt.Sprintf("gparselib.ParseAny(pd, ctx, %s, nil)", gen.newSubs(name, e.children))
+	case pegMulti0:
+		body = fmt.Sprintf("gparselib.ParseMulti0(pd, ctx, %s, nil)", gen.expr(e.children[0], ""))
+	case pegMulti1:
+		body = fmt.Sprintf("gparselib.ParseMulti1(pd, ctx, %s, nil)", gen.expr(e.children[0], ""))
+	case pegOptional:
+		body = fmt.Sprintf("gparselib.ParseOptional(pd, ctx, %s, nil)", gen.expr(e.children[0], ""))
+	case pegNot:
+		body = fmt.Sprintf("gparselib.ParseNot(pd, ctx, %s, nil)", gen.expr(e.children[0], ""))
+	default: // pegAnd
+		body = fmt.Sprintf("gparselib.ParseAnd(pd, ctx, %s, nil)", gen.expr(e.children[0], ""))
+	}
+	gen.methods[m].body = body
+	return "p." + name
+}
+
+func (gen *goGenerator) writeHeader(b *strings.Builder) {
+	fmt.Fprintf(b, "// Code generated by gparsegen from %s. DO NOT EDIT.\n\n", gen.name)
+	fmt.Fprintf(b, "package %s\n\n", gen.pkg)
+	b.WriteString("import (\n\t\"errors\"\n\n\t\"github.com/flowdev/gparselib\"\n)\n\n")
+	fmt.Fprintf(b, "// %s is a parser for the grammar in '%s'.\n", gen.typ, gen.name)
+	fmt.Fprintf(b, "type %s struct {\n", gen.typ)
+	for _, subs := range gen.subs {
+		fmt.Fprintf(b, "\t%s []gparselib.SubparserOp\n", subs.name)
+	}
+	fmt.Fprintf(b, "\tactions [%d]gparselib.SemanticsOp\n", len(gen.actions))
+	fmt.Fprintf(b, "\tregexps [%d]*gparselib.RegexpParser\n", len(gen.regexps))
+	b.WriteString("}\n\n")
+}
+
+func (gen *goGenerator) writeConstructor(b *strings.Builder) {
+	r, size := utf8.DecodeRuneInString(gen.typ)
+	constructor := "New" + gen.typ
+	if unicode.IsLower(r) {
+		constructor = "new" + string(unicode.ToUpper(r)) + gen.typ[size:]
+	}
+	fmt.Fprintf(b, "// %s creates a new parser for the grammar in '%s'.\n", constructor, gen.name)
+	b.WriteString("// The semantic actions used in the grammar are looked up in actions.\n")
+	fmt.Fprintf(b, "func %s(actions map[string]gparselib.SemanticsOp) (*%s, error) {\n", constructor, gen.typ)
+	fmt.Fprintf(b, "\tp := &%s{}\n", gen.typ)
+	for _, subs := range gen.subs {
+		fmt.Fprintf(b, "\tp.%s = []gparselib.SubparserOp{%s}\n", subs.name, strings.Join(subs.ops, ", "))
+	}
+	fmt.Fprintf(b, "\tfor i, name := range [%d]string{", len(gen.actions))
+	for i, a := range gen.actions {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(a))
+	}
+	b.WriteString("} {\n")
+	b.WriteString("\t\tif p.actions[i] = actions[name]; p.actions[i] == nil {\n")
+	b.WriteString("\t\t\treturn nil, errors.New(\"semantic action '\" + name + \"' isn't defined\")\n")
+	b.WriteString("\t\t}\n\t}\n")
+	fmt.Fprintf(b, "\tfor i, re := range [%d]string{", len(gen.regexps))
+	for i, re := range gen.regexps {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(re))
+	}
+	b.WriteString("} {\n")
+	b.WriteString("\t\tvar err error\n")
+	b.WriteString("\t\tif p.regexps[i], err = gparselib.NewRegexpParser(re); err != nil {\n")
+	b.WriteString("\t\t\treturn nil, err\n\t\t}\n\t}\n")
+	b.WriteString("\treturn p, nil\n}\n\n")
+}
+
+func (gen *goGenerator) writeMethods(b *strings.Builder) {
+	start := gen.rules[0].name // a grammar has at least one rule
+	fmt.Fprintf(b, "// Parse parses the input with the start rule '%s'.\n", start)
+	fmt.Fprintf(b, "func (p *%s) Parse(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {\n", gen.typ)
+	fmt.Fprintf(b, "\treturn p.%s(pd, ctx)\n}\n\n", gen.parsers[start])
+
+	b.WriteString("// Rule returns the parser for the named rule or nil if there is no such rule.\n")
+	fmt.Fprintf(b, "func (p *%s) Rule(name string) gparselib.SubparserOp {\n", gen.typ)
+	b.WriteString("\tswitch name {\n")
+	for _, r := range gen.rules {
+		fmt.Fprintf(b, "\tcase %q:\n\t\treturn p.%s\n", r.name, gen.parsers[r.name])
+	}
+	b.WriteString("\t}\n\treturn nil\n}\n")
+
+	for _, m := range gen.methods {
+		fmt.Fprintf(b, "\n// %s\n", m.comment)
+		fmt.Fprintf(b, "func (p *%s) %s(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {\n", gen.typ, m.name)
+		fmt.Fprintf(b, "\treturn %s\n}\n", m.body)
+	}
+}
@@ -0,0 +1,77 @@
+package gparselib
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+//go:generate go run ./cmd/gparsegen -pkg gparselib_test -type calcParser -o calc_parser_test.go testdata/calc.peg
+
+func TestGenerateGo_Golden(t *testing.T) {
+	grammar, err := os.ReadFile("testdata/calc.peg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, err := os.ReadFile("calc_parser_test.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actual := &bytes.Buffer{}
+	fb, err := GenerateGo(actual, "calc.peg", string(grammar), "gparselib_test", "calcParser")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if fb != "" {
+		t.Errorf("expected no feedback, got: %q", fb)
+	}
+	if actual.String() != string(expected) {
+		t.Errorf("generated parser differs from calc_parser_test.go; please run 'go generate'")
+	}
+}
+
+func TestGenerateGo_Errors(t *testing.T) {
+	actual := &bytes.Buffer{}
+	_, err := GenerateGo(actual, "errors.peg", "a <- b {act}\n", "gparselib_test", "Parser")
+	if err == nil || !strings.Contains(err.Error(), "Rule 'b' isn't defined") {
+		t.Errorf("expected error for undefined rule 'b', got: %v", err)
+	}
+	if strings.Contains(err.Error(), "Semantic action") {
+		t.Errorf("didn't expect error for semantic action, got: %v", err)
+	}
+	if actual.Len() != 0 {
+		t.Errorf("didn't expect generated code, got: %q", actual.String())
+	}
+}
+
+func TestGenerateGo_IndirectLeftRecursion(t *testing.T) {
+	actual := &bytes.Buffer{}
+	_, err := GenerateGo(actual, "leftrec.peg", "expr <- sum / num\nsum <- expr '+' num\nnum <- [0-9]+\n",
+		"gparselib_test", "Parser")
+	if err == nil || !strings.Contains(err.Error(), "Rule 'expr' is indirectly left recursive (expr -> sum -> expr)") {
+		t.Errorf("expected error for indirect left recursion, got: %v", err)
+	}
+	if actual.Len() != 0 {
+		t.Errorf("didn't expect generated code, got: %q", actual.String())
+	}
+}
+
+func TestGenerateGo_Names(t *testing.T) {
+	actual := &bytes.Buffer{}
+	_, err := GenerateGo(actual, "names.peg", "Rule <- rule 'x'\nrule <- 'y'\n", "gparselib_test", "Parser")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, s := range []string{
+		"func (p *Parser) parseRule(",
+		"func (p *Parser) parseRule2(",
+		"p.RuleBodySubs = []gparselib.SubparserOp{p.parseRule2, p.RuleLiteral1}",
+		"return p.parseRule2\n",
+	} {
+		if !strings.Contains(actual.String(), s) {
+			t.Errorf("expected generated code containing %q, got:\n%s", s, actual.String())
+		}
+	}
+}
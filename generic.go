@@ -0,0 +1,198 @@
+package gparselib
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// Parser is a type safe parser whose successful results always have a value
+// of type T.
+// It is built on top of the SubparserOp machinery, so positions and feedback
+// are the same as with the other parsers.
+type Parser[T any] struct {
+	op SubparserOp
+}
+
+// Op returns the parser as SubparserOp, so it can be used with all other
+// parsers of this package.
+func (p Parser[T]) Op() SubparserOp {
+	return p.op
+}
+
+// Parse parses the input and returns the value of the result.
+// If the result has an error, the zero value of T is returned.
+func (p Parser[T]) Parse(pd *ParseData, ctx interface{}) (T, *ParseData, interface{}) {
+	pd, ctx = p.op(pd, ctx)
+	if pd.Result.HasError() {
+		var zero T
+		return zero, pd, ctx
+	}
+	return valueOf[T](pd.Result.Value), pd, ctx
+}
+
+// Pair is the value of a sequence of two parsers.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Triple is the value of a sequence of three parsers.
+type Triple[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// Lift turns any subparser into a type safe parser.
+// The function is used to compute the value of successful results.
+func Lift[T any](pluginSubparser SubparserOp, f func(*ParseResult) T) Parser[T] {
+	return Parser[T]{op: func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		pd, ctx = pluginSubparser(pd, ctx)
+		if !pd.Result.HasError() {
+			pd.Result.Value = f(pd.Result)
+		}
+		return pd, ctx
+	}}
+}
+
+// Literal creates a type safe literal parser with the literal as value.
+func Literal(cfgLiteral string) Parser[string] {
+	return Parser[string]{op: NewParseLiteralPlugin(semanticsText, cfgLiteral)}
+}
+
+// Regexp creates a type safe regular expression parser with the matched
+// text as value.
+// If the regular expression is invalid an error is returned.
+func Regexp(cfgRegexp string) (Parser[string], error) {
+	op, err := NewParseRegexpPlugin(nil, cfgRegexp)
+	return Parser[string]{op: op}, err
+}
+
+// Map creates a parser whose value is computed from the value of its
+// subparser.
+func Map[A, B any](p Parser[A], f func(A) B) Parser[B] {
+	return Lift(p.op, func(pr *ParseResult) B {
+		return f(valueOf[A](pr.Value))
+	})
+}
+
+// Seq2 creates a parser calling two parsers in sequence.
+func Seq2[A, B any](pa Parser[A], pb Parser[B]) Parser[Pair[A, B]] {
+	return Parser[Pair[A, B]]{op: NewParseAllPlugin(
+		[]SubparserOp{pa.op, pb.op},
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = Pair[A, B]{
+				First:  valueOf[A](pd.SubResults[0].Value),
+				Second: valueOf[B](pd.SubResults[1].Value),
+			}
+			return pd, ctx
+		},
+	)}
+}
+
+// Seq3 creates a parser calling three parsers in sequence.
+func Seq3[A, B, C any](pa Parser[A], pb Parser[B], pc Parser[C]) Parser[Triple[A, B, C]] {
+	return Parser[Triple[A, B, C]]{op: NewParseAllPlugin(
+		[]SubparserOp{pa.op, pb.op, pc.op},
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = Triple[A, B, C]{
+				First:  valueOf[A](pd.SubResults[0].Value),
+				Second: valueOf[B](pd.SubResults[1].Value),
+				Third:  valueOf[C](pd.SubResults[2].Value),
+			}
+			return pd, ctx
+		},
+	)}
+}
+
+// Choice creates a parser calling its parsers until one matches.
+func Choice[T any](ps ...Parser[T]) Parser[T] {
+	ops := make([]SubparserOp, len(ps))
+	for i, p := range ps {
+		ops[i] = p.op
+	}
+	return Parser[T]{op: NewParseAnyPlugin(ops, nil)}
+}
+
+// Many creates a parser calling its parser as often as possible.
+// It matches even if the parser doesn't match a single time.
+func Many[T any](p Parser[T]) Parser[[]T] {
+	return ManyMinMax(p, 0, math.MaxInt32)
+}
+
+// Many1 creates a parser calling its parser as often as possible.
+// The parser has to match at least one time.
+func Many1[T any](p Parser[T]) Parser[[]T] {
+	return ManyMinMax(p, 1, math.MaxInt32)
+}
+
+// ManyMinMax creates a parser calling its parser multiple times.
+// The minimum times the parser has to match and the maximum times the
+// parser can match have to be configured.
+func ManyMinMax[T any](p Parser[T], cfgMin, cfgMax int) Parser[[]T] {
+	return Parser[[]T]{op: NewParseMultiPlugin(
+		p.op,
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			values := make([]T, len(pd.SubResults))
+			for i, subres := range pd.SubResults {
+				values[i] = valueOf[T](subres.Value)
+			}
+			pd.Result.Value = values
+			return pd, ctx
+		},
+		cfgMin, cfgMax,
+	)}
+}
+
+// Optional creates a parser that always matches (unless its parser fails
+// after a cut).
+// Its value is the default value if its parser doesn't match.
+func Optional[T any](p Parser[T], cfgDefault T) Parser[T] {
+	return Parser[T]{op: func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		failed := false
+		pd, ctx = ParseOptional(pd, ctx, func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd, ctx = p.op(pd, ctx)
+			failed = pd.Result.HasError()
+			return pd, ctx
+		}, nil)
+		if failed && !pd.Result.HasError() {
+			pd.Result.Value = cfgDefault
+		}
+		return pd, ctx
+	}}
+}
+
+// Lazy creates a parser that is only created when it is used for the first
+// time.
+// So recursive parsers can be built.
+// The parser can be used concurrently.
+func Lazy[T any](f func() Parser[T]) Parser[T] {
+	var op SubparserOp
+	var once sync.Once
+	return Parser[T]{op: func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		once.Do(func() {
+			op = f().op
+		})
+		if pd.describer != nil {
+			return describeLazy(pd, ctx, &op, op)
+		}
+		return op(pd, ctx)
+	}}
+}
+
+// valueOf returns the value as T or the zero value of T if it is nil.
+// A value of another type is a programming error (e.g.: semantics of a
+// subparser setting the wrong value), so valueOf panics.
+func valueOf[T any](value interface{}) T {
+	if value == nil {
+		var zero T
+		return zero
+	}
+	t, ok := value.(T)
+	if !ok {
+		panic(fmt.Sprintf("gparselib: the value %#v of type %T isn't of type %v", value, value, reflect.TypeFor[T]()))
+	}
+	return t
+}
@@ -0,0 +1,154 @@
+package gparselib
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestParser_Seq(t *testing.T) {
+	number, err := Regexp(`[0-9]+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	num := Map(number, func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	})
+	sign := Optional(Choice(Literal("+"), Literal("-")), "+")
+	signedNum := Map(Seq2(sign, num), func(p Pair[string, int]) int {
+		if p.First == "-" {
+			return -p.Second
+		}
+		return p.Second
+	})
+	sum := Map(Seq3(signedNum, Literal(","), Many(Map(Seq2(Literal(","), signedNum), func(p Pair[string, int]) int {
+		return p.Second
+	}))), func(t Triple[int, string, []int]) int {
+		s := t.First
+		for _, n := range t.Third {
+			s += n
+		}
+		return s
+	})
+
+	runTests(t, signedNum.Op(), []parseTestData{
+		{
+			givenParseData:   newData("empty", 0, ""),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 1,
+		}, {
+			givenParseData:   newData("no sign", 0, "12"),
+			expectedResult:   newResult(0, "12", 12, -1),
+			expectedSrcPos:   2,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("minus", 1, "x-12"),
+			expectedResult:   newResult(1, "-12", -12, -1),
+			expectedSrcPos:   4,
+			expectedErrCount: 0,
+		},
+	})
+	runTests(t, sum.Op(), []parseTestData{
+		{
+			givenParseData:   newData("sum", 0, "1,,2,-4,+10"),
+			expectedResult:   newResult(0, "1,,2,-4,+10", 9, -1),
+			expectedSrcPos:   11,
+			expectedErrCount: 0,
+		},
+	})
+
+	v, pd, _ := sum.Parse(NewParseData("parse", "-3,,3,3"), nil)
+	if v != 3 || pd.Result.HasError() {
+		t.Errorf("expected value 3 without error, got: %d, %v", v, pd.Result.Feedback)
+	}
+	v, pd, _ = sum.Parse(NewParseData("parse error", "3"), nil)
+	if v != 0 || !pd.Result.HasError() {
+		t.Errorf("expected zero value with error, got: %d, %v", v, pd.Result.Feedback)
+	}
+}
+
+func TestParser_Lazy(t *testing.T) {
+	var nested Parser[int]
+	nested = Lazy(func() Parser[int] {
+		return Choice(
+			Map(Seq3(Literal("("), nested, Literal(")")), func(t Triple[string, int, string]) int {
+				return t.Second + 1
+			}),
+			Lift(NewParseLiteralPlugin(nil, "x"), func(*ParseResult) int { return 0 }),
+		)
+	})
+
+	runTests(t, nested.Op(), []parseTestData{
+		{
+			givenParseData:   newData("no parens", 0, "x"),
+			expectedResult:   newResult(0, "x", 0, -1),
+			expectedSrcPos:   1,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("3 parens", 0, "(((x)))"),
+			expectedResult:   newResult(0, "(((x)))", 3, -1),
+			expectedSrcPos:   7,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("not closed", 0, "((x)"),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 2,
+		},
+	})
+}
+
+func TestParser_LazyConcurrent(t *testing.T) {
+	p := Lazy(func() Parser[string] { return Literal("x") })
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, pd, _ := p.Parse(NewParseData("concurrent", "x"), nil); v != "x" {
+				t.Errorf("expected value 'x', got: %q, %v", v, pd.Result.Feedback)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParser_WrongValueType(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		expected := `gparselib: the value "x" of type string isn't of type int`
+		if msg != expected {
+			t.Errorf("expected panic %q, got: %q", expected, msg)
+		}
+	}()
+	p := Parser[int]{op: Literal("x").Op()}
+	p.Parse(NewParseData("wrong type", "x"), nil)
+}
+
+func TestParser_Many(t *testing.T) {
+	p := ManyMinMax(Literal("ab"), 2, 3)
+
+	runTests(t, p.Op(), []parseTestData{
+		{
+			givenParseData:   newData("1 match", 0, "abba"),
+			expectedResult:   newResult(0, "", nil, 2),
+			expectedSrcPos:   0,
+			expectedErrCount: 2,
+		}, {
+			givenParseData:   newData("3 matches", 0, "abababab"),
+			expectedResult:   newResult(0, "ababab", []string{"ab", "ab", "ab"}, -1),
+			expectedSrcPos:   6,
+			expectedErrCount: 0,
+		},
+	})
+	runTests(t, Many1(Literal("ab")).Op(), []parseTestData{
+		{
+			givenParseData:   newData("no match", 0, "ba"),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 2,
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package gparselib_test
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/flowdev/gparselib"
+)
+
+// calcParsers returns the calculator grammar loaded with LoadGrammar and the
+// parser generated from it.
+func calcParsers(tb testing.TB) (*gparselib.Grammar, *calcParser) {
+	toInt := func(v interface{}) int {
+		return v.(int)
+	}
+	actions := map[string]gparselib.SemanticsOp{
+		"add": func(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+			pd.Result.Value = toInt(pd.SubResults[0].Value) + toInt(pd.SubResults[3].Value)
+			return pd, ctx
+		},
+		"sub": func(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+			pd.Result.Value = toInt(pd.SubResults[0].Value) - toInt(pd.SubResults[3].Value)
+			return pd, ctx
+		},
+		"paren": func(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+			pd.Result.Value = pd.SubResults[2].Value
+			return pd, ctx
+		},
+		"number": func(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+			pd.Result.Value, _ = strconv.Atoi(pd.SubResults[0].Text)
+			return pd, ctx
+		},
+		"text": func(pd *gparselib.ParseData, ctx interface{}) (*gparselib.ParseData, interface{}) {
+			pd.Result.Value = pd.Result.Text
+			return pd, ctx
+		},
+	}
+	grammar, err := os.ReadFile("testdata/calc.peg")
+	if err != nil {
+		tb.Fatalf("unexpected error: %v", err)
+	}
+	interpreted, _, err := gparselib.LoadGrammar("calc.peg", string(grammar), actions)
+	if err != nil {
+		tb.Fatalf("unexpected error: %v", err)
+	}
+	generated, err := newCalcParser(actions)
+	if err != nil {
+		tb.Fatalf("unexpected error: %v", err)
+	}
+	return interpreted, generated
+}
+
+func TestGeneratedParser(t *testing.T) {
+	interpreted, generated := calcParsers(t)
+
+	specs := []struct {
+		rule   string
+		inputs []string
+	}{
+		{
+			rule:   "calc",
+			inputs: []string{"", "1", " 1 + 2 - 3", "(1+2)-( 3 - 4 )\n", "1 +", "(1", "1 2", "1-(2+)"},
+		}, {
+			rule:   "keyword",
+			inputs: []string{"", "if", "iffy", "else", "x"},
+		}, {
+			rule:   "any",
+			inputs: []string{"", "a", "ab", "abc", "b"},
+		},
+	}
+
+	for _, spec := range specs {
+		for _, input := range spec.inputs {
+			t.Run(spec.rule+":"+input, func(tt *testing.T) {
+				pdi := gparselib.NewParseData("input", input)
+				pdi.Memo = gparselib.NewMemoTable(0)
+				pdi, _ = interpreted.Ref(spec.rule)(pdi, nil)
+				pdg := gparselib.NewParseData("input", input)
+				pdg.Memo = gparselib.NewMemoTable(0)
+				pdg, _ = generated.Rule(spec.rule)(pdg, nil)
+
+				ri, rg := pdi.Result, pdg.Result
+				if ri.Pos != rg.Pos || ri.Text != rg.Text || ri.ErrPos != rg.ErrPos ||
+					!reflect.DeepEqual(ri.Value, rg.Value) {
+					tt.Errorf("interpreted result %#v differs from generated result %#v", ri, rg)
+				}
+				fbi, erri := pdi.GetFeedback()
+				fbg, errg := pdg.GetFeedback()
+				if fbi != fbg {
+					tt.Errorf("interpreted feedback %q differs from generated feedback %q", fbi, fbg)
+				}
+				if (erri == nil) != (errg == nil) || (erri != nil && erri.Error() != errg.Error()) {
+					tt.Errorf("interpreted error %q differs from generated error %q", erri, errg)
+				}
+			})
+		}
+	}
+	if generated.Rule("unknown") != nil {
+		t.Errorf("didn't expect a parser for an unknown rule")
+	}
+}
+
+func TestGeneratedParser_Describe(t *testing.T) {
+	_, generated := calcParsers(t)
+	space := `[ \t\n]*`
+	term := "([0-9]+ " + space + " / '(' " + space + " expr ')' " + space + ")"
+	expr := "expr '+' " + space + " " + term + " / expr '-' " + space + " " + term + " / " + term
+	for rule, expected := range map[string]string{
+		"expr": "expr <- " + expr,
+		"calc": "calc <- " + space + " (" + expr + ") !.",
+	} {
+		if got := gparselib.Describe(generated.Rule(rule)).String(); got != expected {
+			t.Errorf("expected description:\n%s\ngot:\n%s", expected, got)
+		}
+	}
+}
+
+func BenchmarkGeneratedParser(b *testing.B) {
+	interpreted, generated := calcParsers(b)
+	input := strings.Repeat("(1 + 2) - (3 - 4) + ", 100) + "5"
+	parsers := []struct {
+		name   string
+		parser gparselib.SubparserOp
+	}{
+		{name: "interpreted", parser: interpreted.Start()},
+		{name: "generated", parser: generated.Parse},
+	}
+	for _, p := range parsers {
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pd := gparselib.NewParseData("bench", input)
+				pd.Memo = gparselib.NewMemoTable(0)
+				pd, _ = p.parser(pd, nil)
+				if pd.Result.HasError() {
+					b.Fatalf("unexpected error: %v", pd.Result.Feedback)
+				}
+			}
+		})
+	}
+}
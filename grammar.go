@@ -0,0 +1,126 @@
+package gparselib
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Grammar is a registry of named rules.
+// Rules can be referenced before they are defined, so recursive grammars
+// can be built without helper variables and closures.
+type Grammar struct {
+	rules map[string]*rule
+	order []string // rule names in order of first appearance
+	dups  []string // names of rules that are defined more than once
+	start string   // name of the first defined rule
+}
+
+// rule is a named subparser.
+// Its subparser is nil as long as it is only referenced.
+type rule struct {
+	name       string
+	subparser  SubparserOp
+	referenced bool
+}
+
+// NewGrammar creates a new, completely initialized Grammar.
+func NewGrammar() *Grammar {
+	return &Grammar{rules: make(map[string]*rule, 64)}
+}
+
+// Define defines the rule with the given name.
+// Defining the same rule multiple times is reported by Validate.
+func (g *Grammar) Define(name string, pluginSubparser SubparserOp) {
+	r := g.rule(name)
+	if r.subparser != nil {
+		g.dups = append(g.dups, name)
+	}
+	if g.start == "" {
+		g.start = name
+	}
+	r.subparser = pluginSubparser
+}
+
+// Start returns a subparser for the first defined rule of the grammar.
+// If no rule is defined yet, the subparser always fails.
+func (g *Grammar) Start() SubparserOp {
+	if g.start == "" {
+		return parseNoStart
+	}
+	return g.Ref(g.start)
+}
+
+// parseNoStart is the start rule of a grammar without any rule.
+func parseNoStart(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	createUnmatchedResult(pd, 0, "No start rule defined", nil)
+	return pd, ctx
+}
+
+// Ref returns a subparser for the rule with the given name.
+// The rule doesn't have to be defined yet.
+func (g *Grammar) Ref(name string) SubparserOp {
+	r := g.rule(name)
+	r.referenced = true
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return parseRule(pd, ctx, r)
+	}
+}
+
+// Validate reports rules that are referenced but never defined and rules that
+// are defined multiple times.
+func (g *Grammar) Validate() error {
+	var errs []error
+	for _, name := range g.order {
+		if g.rules[name].subparser == nil {
+			errs = append(errs, fmt.Errorf("rule '%s' is referenced but never defined", name))
+		}
+	}
+	for _, name := range g.dups {
+		errs = append(errs, fmt.Errorf("rule '%s' is defined multiple times", name))
+	}
+	return errors.Join(errs...)
+}
+
+func (g *Grammar) rule(name string) *rule {
+	r := g.rules[name]
+	if r == nil {
+		r = &rule{name: name}
+		g.rules[name] = r
+		g.order = append(g.order, name)
+	}
+	return r
+}
+
+// parseRule calls the subparser of the rule.
+func parseRule(pd *ParseData, ctx interface{}, r *rule) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindRef, Name: r.name})
+	}
+	if r.subparser == nil {
+		createUnmatchedResult(pd, 0, "Rule '"+r.name+"' isn't defined", nil)
+		return pd, ctx
+	}
+	return ParseRule(pd, ctx, r.subparser, r.name)
+}
+
+// ParseRule calls its subparser and makes the named rule the current rule
+// while doing so.
+// The name of the current rule is part of all feedback.
+// The configuration has to be the name of the rule.
+func ParseRule(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp,
+	cfgRule string,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeNamed(pd, ctx, &Descriptor{Kind: KindRule, Name: cfgRule}, pluginSubparser)
+	}
+	parentRule := pd.ruleName
+	pd.ruleName = cfgRule
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "rule"))
+	}
+	pd, ctx = pluginSubparser(pd, ctx)
+	pd.ruleName = parentRule
+	return pd, ctx
+}
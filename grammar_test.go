@@ -0,0 +1,106 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrammar_Recursion(t *testing.T) {
+	g := NewGrammar()
+	g.Define("list", NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "("),
+		NewParseMulti0Plugin(g.Ref("list"), nil),
+		NewParseLiteralPlugin(nil, ")"),
+	}, nil))
+	if err := g.Validate(); err != nil {
+		t.Fatalf("expected valid grammar, got: %v", err)
+	}
+
+	runTests(t, g.Ref("list"), []parseTestData{
+		{
+			givenParseData:   newData("empty", 0, ""),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 1,
+		}, {
+			givenParseData:   newData("simple", 0, "()"),
+			expectedResult:   newResult(0, "()", []interface{}{nil, []interface{}{}, nil}, -1),
+			expectedSrcPos:   2,
+			expectedErrCount: 0,
+		}, {
+			givenParseData: newData("nested", 0, "(()(()))"),
+			expectedResult: newResult(0, "(()(()))", []interface{}{nil, []interface{}{
+				[]interface{}{nil, []interface{}{}, nil},
+				[]interface{}{nil, []interface{}{
+					[]interface{}{nil, []interface{}{}, nil},
+				}, nil},
+			}, nil}, -1),
+			expectedSrcPos:   8,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("not closed", 0, "(()"),
+			expectedResult:   newResult(0, "", nil, 3),
+			expectedSrcPos:   0,
+			expectedErrCount: 1,
+		},
+	})
+}
+
+func TestGrammar_Validate(t *testing.T) {
+	g := NewGrammar()
+	g.Define("start", NewParseAllPlugin([]SubparserOp{g.Ref("a"), g.Ref("b")}, nil))
+	g.Define("a", NewParseLiteralPlugin(nil, "a"))
+	g.Define("a", NewParseLiteralPlugin(nil, "A"))
+
+	err := g.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, s := range []string{
+		"rule 'b' is referenced but never defined",
+		"rule 'a' is defined multiple times",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected error containing %q, got: %q", s, err.Error())
+		}
+	}
+
+	pd, _ := g.Ref("start")(NewParseData("undefined", "Ab"), nil)
+	_, err = pd.GetFeedback()
+	if err == nil || !strings.Contains(err.Error(), "In rule 'start': Rule 'b' isn't defined") {
+		t.Errorf("expected error for undefined rule 'b', got: %v", err)
+	}
+}
+
+func TestGrammar_NoStart(t *testing.T) {
+	g := NewGrammar()
+	pd, _ := g.Start()(NewParseData("empty", "a"), nil)
+	_, err := pd.GetFeedback()
+	if err == nil || !strings.Contains(err.Error(), "No start rule defined") {
+		t.Errorf("expected error for missing start rule, got: %v", err)
+	}
+	if err := g.Validate(); err != nil {
+		t.Errorf("expected no empty rule to be registered, got: %v", err)
+	}
+	if len(g.Describe()) != 0 {
+		t.Errorf("expected no rules, got: %v", g)
+	}
+}
+
+func TestGrammar_RuleInMessage(t *testing.T) {
+	g := NewGrammar()
+	g.Define("start", NewParseAllPlugin([]SubparserOp{g.Ref("keyword"), NewParseEOFPlugin(nil)}, nil))
+	g.Define("keyword", NewParseLiteralPlugin(nil, "func"))
+
+	pd, _ := g.Ref("start")(NewParseData("msg", "fun"), nil)
+	_, err := pd.GetFeedback()
+	if err == nil || !strings.Contains(err.Error(), "In rule 'keyword': Literal 'func' expected") {
+		t.Errorf("expected error for rule 'keyword', got: %v", err)
+	}
+
+	pd, _ = g.Ref("start")(NewParseData("msg", "func "), nil)
+	_, err = pd.GetFeedback()
+	if err == nil || !strings.Contains(err.Error(), "In rule 'start': Expecting end of input") {
+		t.Errorf("expected error for rule 'start', got: %v", err)
+	}
+}
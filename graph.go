@@ -0,0 +1,235 @@
+package gparselib
+
+import (
+	"regexp"
+	"slices"
+)
+
+// ruleGraph contains the references between the rules of a grammar.
+type ruleGraph struct {
+	descs    []*Descriptor       // all rules in order of first appearance
+	refs     map[string][]string // rules referenced by a rule (without duplicates)
+	nullable map[string]bool     // rules that can match without consuming input
+	leftRefs map[string][]string // rules referenced without consuming input before
+}
+
+func newRuleGraph(g *Grammar) *ruleGraph {
+	return newDescribedRuleGraph(g.Describe())
+}
+
+// newDescribedRuleGraph creates the graph of the described rules (KindRule).
+func newDescribedRuleGraph(descs []*Descriptor) *ruleGraph {
+	rg := &ruleGraph{
+		descs:    descs,
+		refs:     make(map[string][]string, len(descs)),
+		nullable: make(map[string]bool, len(descs)),
+		leftRefs: make(map[string][]string, len(descs)),
+	}
+	for _, d := range rg.descs {
+		rg.refs[d.Name] = uniqueNames(descRefs(d, nil))
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, d := range rg.descs {
+			if !rg.nullable[d.Name] && descNullable(d, rg.nullable) {
+				rg.nullable[d.Name] = true
+				changed = true
+			}
+		}
+	}
+	for _, d := range rg.descs {
+		rg.leftRefs[d.Name] = uniqueNames(descLeftRefs(d, rg.nullable, nil))
+	}
+	return rg
+}
+
+// leftRecursive returns true if the rule can call the other rule without
+// consuming input and the other rule can call the rule the same way.
+// So a left recursive rule is left recursive to itself.
+func (rg *ruleGraph) leftRecursive(rule, other string) bool {
+	if !slices.Contains(rg.leftRefs[rule], other) {
+		return false
+	}
+	seen := make(map[string]bool, len(rg.descs))
+	todo := []string{other}
+	for len(todo) > 0 {
+		name := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		for _, ref := range rg.leftRefs[name] {
+			if ref == rule {
+				return true
+			}
+			if !seen[ref] {
+				seen[ref] = true
+				todo = append(todo, ref)
+			}
+		}
+	}
+	return false
+}
+
+// leftCycle returns the shortest cycle of rules that call each other
+// without consuming input starting and ending with the rule (e.g.:
+// a -> b -> a) or nil if there is none.
+// If direct recursion is skipped, only cycles through other rules are found.
+func (rg *ruleGraph) leftCycle(rule string, skipDirect bool) []string {
+	prev := map[string]string{}
+	todo := []string{rule}
+	for len(todo) > 0 {
+		name := todo[0]
+		todo = todo[1:]
+		for _, ref := range rg.leftRefs[name] {
+			if ref == rule && name == rule && skipDirect {
+				continue
+			}
+			if ref == rule {
+				cycle := []string{rule}
+				for n := name; n != rule; n = prev[n] {
+					cycle = append(cycle, n)
+				}
+				cycle = append(cycle, rule)
+				slices.Reverse(cycle[1 : len(cycle)-1])
+				return cycle
+			}
+			if _, ok := prev[ref]; !ok && ref != rule {
+				prev[ref] = name
+				todo = append(todo, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// cycles returns the rules of all recursion cycles (the strongly connected
+// components of the graph that contain at least one reference).
+// The cycles and their rules are in order of first appearance.
+func (rg *ruleGraph) cycles() [][]string {
+	// Tarjan's algorithm
+	index := make(map[string]int, len(rg.descs))
+	low := make(map[string]int, len(rg.descs))
+	onStack := make(map[string]bool, len(rg.descs))
+	var stack []string
+	var cycles [][]string
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		low[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, ref := range rg.refs[name] {
+			if _, ok := index[ref]; !ok {
+				visit(ref)
+				low[name] = min(low[name], low[ref])
+			} else if onStack[ref] {
+				low[name] = min(low[name], index[ref])
+			}
+		}
+		if low[name] != index[name] {
+			return
+		}
+		i := slices.Index(stack, name)
+		cycle := slices.Clone(stack[i:])
+		stack = stack[:i]
+		for _, n := range cycle {
+			onStack[n] = false
+		}
+		if len(cycle) > 1 || slices.Contains(rg.refs[name], name) {
+			cycles = append(cycles, cycle)
+		}
+	}
+	for _, d := range rg.descs {
+		if _, ok := index[d.Name]; !ok {
+			visit(d.Name)
+		}
+	}
+	slices.SortFunc(cycles, func(a, b []string) int { return rg.order(a[0]) - rg.order(b[0]) })
+	for _, cycle := range cycles {
+		slices.SortFunc(cycle, func(a, b string) int { return rg.order(a) - rg.order(b) })
+	}
+	return cycles
+}
+
+// order returns the index of the rule in order of first appearance.
+func (rg *ruleGraph) order(rule string) int {
+	return slices.IndexFunc(rg.descs, func(d *Descriptor) bool { return d.Name == rule })
+}
+
+func uniqueNames(names []string) []string {
+	unique := names[:0]
+	for _, name := range names {
+		if !slices.Contains(unique, name) {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// descRefs appends the names of all rules referenced by the described parser.
+func descRefs(d *Descriptor, refs []string) []string {
+	if d.Kind == KindRef {
+		return append(refs, d.Name)
+	}
+	for _, c := range d.Children {
+		refs = descRefs(c, refs)
+	}
+	return refs
+}
+
+// descNullable returns true if the described parser can match without
+// consuming any input.
+// Parsers that aren't from this package are expected to consume input.
+func descNullable(d *Descriptor, nullable map[string]bool) bool {
+	switch d.Kind {
+	case KindLiteral:
+		return d.Config[0] == ""
+	case KindRegexp:
+		re, err := regexp.Compile(`^(?:` + d.Config[0] + `)`)
+		return err == nil && re.MatchString("")
+	case KindEOF, KindCut, KindOptional, KindNot, KindAnd, KindStream:
+		return true
+	case KindRef:
+		return nullable[d.Name]
+	case KindMulti:
+		return d.Min <= 0 || descNullable(d.Children[0], nullable)
+	case KindAny, KindBest:
+		for _, c := range d.Children {
+			if descNullable(c, nullable) {
+				return true
+			}
+		}
+		return false
+	case KindAll, KindCustom:
+		for _, c := range d.Children {
+			if !descNullable(c, nullable) {
+				return false
+			}
+		}
+		return len(d.Children) > 0 || d.Kind == KindAll
+	case KindLabel, KindRecover, KindMemo, KindLeftRec, KindRule:
+		return len(d.Children) > 0 && descNullable(d.Children[0], nullable)
+	default: // the other simple parsers
+		return false
+	}
+}
+
+// descLeftRefs appends the names of all rules that can be called by the
+// described parser without consuming any input.
+func descLeftRefs(d *Descriptor, nullable map[string]bool, refs []string) []string {
+	switch d.Kind {
+	case KindRef:
+		return append(refs, d.Name)
+	case KindAll, KindCustom:
+		for _, c := range d.Children {
+			refs = descLeftRefs(c, nullable, refs)
+			if !descNullable(c, nullable) {
+				break
+			}
+		}
+		return refs
+	default:
+		for _, c := range d.Children {
+			refs = descLeftRefs(c, nullable, refs)
+		}
+		return refs
+	}
+}
@@ -0,0 +1,165 @@
+package gparselib
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Edit is a change of the content of a source: the bytes from Start to End
+// (exclusive) are replaced by Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// NewIncrementalParseData creates a new ParseData whose results can be
+// reused after edits (see Edit).
+// It uses an unlimited MemoTable that records exactly which part of the
+// content every memoized result depends on.
+func NewIncrementalParseData(name string, content string) *ParseData {
+	pd := NewParseData(name, content)
+	pd.Memo = NewMemoTable(0)
+	pd.Memo.incremental = true
+	return pd
+}
+
+// Edit returns new ParseData for the content changed by the edits.
+// All positions of the edits are relative to the old content and the edits
+// must not overlap.
+// Insertions at the same position are inserted in the order of the edits.
+// The memoized results that don't depend on edited content are kept with
+// shifted positions, so parsing the new content again only has to parse
+// the changed parts.
+// Results with feedback about edited content aren't kept either.
+// The results are the same as for parsing the new content from scratch
+// as long as the semantics of memoized rules don't depend on the context
+// or positions.
+// Memoized results are only found if pd.Memo is set (see
+// NewIncrementalParseData).
+func (pd *ParseData) Edit(edits ...Edit) (*ParseData, error) {
+	if pd.Source.stream != nil {
+		return nil, errors.New("the content of a streamed source can't be edited")
+	}
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a, b Edit) int {
+		return a.Start - b.Start
+	})
+	content := pd.Source.content
+	b := strings.Builder{}
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End < e.Start || e.End > len(content) {
+			return nil, fmt.Errorf("edit [%d, %d) is out of range or overlaps another edit", e.Start, e.End)
+		}
+		b.WriteString(content[last:e.Start])
+		b.WriteString(e.Text)
+		last = e.End
+	}
+	b.WriteString(content[last:])
+
+	npd := NewParseData(pd.Source.Name, b.String())
+	npd.Source.colMode = pd.Source.colMode
+	npd.Source.tabWidth = pd.Source.tabWidth
+	if pd.Memo == nil {
+		return npd, nil
+	}
+	npd.Memo = NewMemoTable(pd.Memo.maxEntries)
+	npd.Memo.incremental = pd.Memo.incremental
+	for key, e := range pd.Memo.entries {
+		// keys are global positions for sources of a SourceSet
+		pos := key.pos - pd.Source.base
+		if pos < 0 || pos > len(content) { // result of another source of the set
+			continue
+		}
+		start, end := resultSpan(e.result, pos, e.reach)
+		delta, ok := editDelta(edits, start, end)
+		if !ok {
+			continue
+		}
+		key.pos = pos + delta
+		npd.Memo.entries[key] = &memoEntry{
+			result: shiftResult(e.result, delta, &pd.Source, &npd.Source),
+			newPos: e.newPos + delta,
+			reach:  e.reach + delta,
+		}
+	}
+	return npd, nil
+}
+
+// editDelta returns how far content examined from pos to reach (exclusive)
+// is moved by the edits.
+// If the content is changed by an edit, false is returned.
+func editDelta(edits []Edit, pos, reach int) (int, bool) {
+	delta := 0
+	for _, e := range edits {
+		switch {
+		case reach <= e.Start:
+			return delta, true
+		case pos >= e.End:
+			delta += len(e.Text) - (e.End - e.Start)
+		default:
+			return 0, false
+		}
+	}
+	return delta, true
+}
+
+// resultSpan returns the span of content a memoized result from pos to reach
+// (exclusive) depends on.
+// It includes the characters its feedback reports about, so feedback is
+// never moved when its own position is edited.
+func resultSpan(pr *ParseResult, pos, reach int) (int, int) {
+	for _, fb := range pr.Feedback {
+		pos = min(pos, fb.Pos)
+		reach = max(reach, fb.Pos+1)
+	}
+	return pos, reach
+}
+
+// shiftResult copies the result with all positions moved by delta.
+// Feedback of the old source is moved to the new source.
+func shiftResult(pr *ParseResult, delta int, oldSrc, newSrc *SourceData) *ParseResult {
+	cp := *pr
+	cp.Pos += delta
+	if cp.ErrPos >= 0 {
+		cp.ErrPos += delta
+	}
+	cp.Feedback = make([]*FeedbackItem, len(pr.Feedback))
+	for i, fb := range pr.Feedback {
+		cp.Feedback[i] = &FeedbackItem{
+			Pos:  fb.Pos + delta,
+			Kind: fb.Kind,
+			Msg:  shiftMessage(fb.Msg, delta, oldSrc, newSrc),
+		}
+	}
+	return &cp
+}
+
+// shiftMessage moves the position of messages about the old source.
+func shiftMessage(msg fmt.Stringer, delta int, oldSrc, newSrc *SourceData) fmt.Stringer {
+	switch m := msg.(type) {
+	case *ParseError:
+		if m.Source != oldSrc.Name {
+			return m
+		}
+		return allocParseError(ParseError{
+			Source:   m.Source,
+			Offset:   m.Offset + delta,
+			Rule:     m.Rule,
+			Msg:      m.Msg,
+			Expected: m.Expected,
+			Err:      m.Err,
+			src:      newSrc,
+		})
+	case *parseMessage:
+		if m.src != oldSrc && (m.src != nil || m.where == "") {
+			return m
+		}
+		return &parseMessage{src: newSrc, pos: m.pos + delta, rule: m.rule, msg: m.msg}
+	default:
+		return msg
+	}
+}
@@ -0,0 +1,202 @@
+package gparselib
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func loadCalcGrammar(t *testing.T) *Grammar {
+	toInt := func(v interface{}) int {
+		i, _ := v.(int)
+		return i
+	}
+	actions := map[string]SemanticsOp{
+		"add": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = toInt(pd.SubResults[0].Value) + toInt(pd.SubResults[3].Value)
+			return pd, ctx
+		},
+		"sub": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = toInt(pd.SubResults[0].Value) - toInt(pd.SubResults[3].Value)
+			return pd, ctx
+		},
+		"paren": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = pd.SubResults[2].Value
+			return pd, ctx
+		},
+		"number": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value, _ = strconv.Atoi(pd.SubResults[0].Text)
+			return pd, ctx
+		},
+		"text": semanticsText,
+	}
+	text, err := os.ReadFile("testdata/calc.peg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g, _, err := LoadGrammar("calc.peg", string(text), actions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return g
+}
+
+// calcValue returns the value of the expression of the calc rule.
+func calcValue(pd *ParseData) interface{} {
+	vs, _ := pd.Result.Value.([]interface{})
+	if len(vs) < 2 {
+		return nil
+	}
+	return vs[1]
+}
+
+func TestParseData_Edit(t *testing.T) {
+	calc := loadCalcGrammar(t).Start()
+
+	pd := NewIncrementalParseData("input", "1 + 2\n- (3 + 4)")
+	pd, _ = calc(pd, nil)
+	if v := calcValue(pd); v != -4 {
+		t.Fatalf("expected value -4, got: %#v", v)
+	}
+
+	npd, err := pd.Edit(Edit{Start: 2, End: 3, Text: "-"}, Edit{Start: 15, End: 15, Text: " + 10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if npd.Source.content != "1 - 2\n- (3 + 4) + 10" {
+		t.Fatalf("unexpected content after edits: %q", npd.Source.content)
+	}
+	reused := npd.Memo.Len()
+	if reused == 0 {
+		t.Errorf("expected some results to be reused")
+	}
+	npd, _ = calc(npd, nil)
+	if v := calcValue(npd); v != 2 {
+		t.Errorf("expected value 2, got: %#v", v)
+	}
+	if npd.Memo.Stats().Hits < reused/2 {
+		t.Errorf("expected the reused results to be used, got: %+v (reused %d)", npd.Memo.Stats(), reused)
+	}
+
+	npd, err = pd.Edit(Edit{Start: 15, End: 15, Text: " + 2"}, Edit{Start: 0, End: 0, Text: "("},
+		Edit{Start: 15, End: 15, Text: ")"}, Edit{Start: 15, End: 15, Text: " - 1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if npd.Source.content != "(1 + 2\n- (3 + 4) + 2) - 1" {
+		t.Fatalf("expected the insertions at the same position in order, got: %q", npd.Source.content)
+	}
+	npd, _ = calc(npd, nil)
+	if v := calcValue(npd); v != -3 {
+		t.Errorf("expected value -3, got: %#v", v)
+	}
+
+	for _, edits := range [][]Edit{
+		{{Start: 3, End: 2}},
+		{{Start: 0, End: 99}},
+		{{Start: 0, End: 3}, {Start: 2, End: 4}},
+	} {
+		if _, err = pd.Edit(edits...); err == nil {
+			t.Errorf("expected an error for the edits %+v", edits)
+		}
+	}
+}
+
+func TestParseData_Edit_Feedback(t *testing.T) {
+	// the warning is about a character the parser doesn't examine
+	pWord := NewParseMemoPlugin("word", NewParseLiteralPlugin(
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			end := pd.Source.pos
+			pd.AddWarning(end, "'ab' is followed by '"+pd.Source.content[end:end+1]+"'")
+			return pd, ctx
+		}, "ab"))
+	pRest, err := NewParseRegexpPlugin(nil, `[a-z]*`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := NewParseAllPlugin([]SubparserOp{pWord, pRest}, nil)
+
+	pd, _ := p(NewIncrementalParseData("input", "abc"), nil)
+	npd, err := pd.Edit(Edit{Start: 2, End: 3, Text: "d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if npd.Memo.Len() != 0 {
+		t.Errorf("expected the result with feedback about the edit to be dropped, got %d results", npd.Memo.Len())
+	}
+	npd, _ = p(npd, nil)
+	info, _ := npd.GetFeedback()
+	if !strings.Contains(info, "'ab' is followed by 'd'") {
+		t.Errorf("expected feedback about the edited content, got: %q", info)
+	}
+}
+
+// TestParseData_Edit_Random compares incremental parsing after random edits
+// with parsing from scratch.
+func TestParseData_Edit_Random(t *testing.T) {
+	calc := loadCalcGrammar(t).Start()
+	rnd := rand.New(rand.NewSource(4711))
+	const alphabet = "0123456789+-()  \n"
+	randomText := func(n int) string {
+		b := strings.Builder{}
+		for i := 0; i < n; i++ {
+			b.WriteByte(alphabet[rnd.Intn(len(alphabet))])
+		}
+		return b.String()
+	}
+	newData := func(incremental bool, content string) *ParseData {
+		if incremental {
+			return NewIncrementalParseData("input", content)
+		}
+		pd := NewParseData("input", content)
+		pd.Memo = NewMemoTable(0)
+		return pd
+	}
+
+	misses, freshMisses := 0, 0
+	for i := 0; i < 2000; i++ {
+		incremental := i%2 == 0
+		content := randomText(rnd.Intn(30))
+		if rnd.Intn(2) == 0 { // make valid expressions likely
+			content = strings.Repeat("(1 + 23) - 4\n+ ", rnd.Intn(4)) + "5"
+		}
+		pd, _ := calc(newData(incremental, content), nil)
+
+		var edits []Edit
+		for pos := 0; pos <= len(content); {
+			start := pos + rnd.Intn(len(content)-pos+1)
+			end := start + rnd.Intn(min(3, len(content)-start)+1)
+			edits = append(edits, Edit{Start: start, End: end, Text: randomText(rnd.Intn(3))})
+			pos = end + 1 + rnd.Intn(10)
+			if rnd.Intn(2) == 0 {
+				break
+			}
+		}
+		npd, err := pd.Edit(edits...)
+		if err != nil {
+			t.Fatalf("content %q, edits %+v: unexpected error: %v", content, edits, err)
+		}
+		npd, _ = calc(npd, nil)
+		fpd, _ := calc(newData(incremental, npd.Source.content), nil)
+		misses += npd.Memo.Stats().Misses
+		freshMisses += fpd.Memo.Stats().Misses
+
+		nr, fr := npd.Result, fpd.Result
+		if nr.Pos != fr.Pos || nr.Text != fr.Text || nr.ErrPos != fr.ErrPos || !reflect.DeepEqual(nr.Value, fr.Value) {
+			t.Fatalf("content %q, edits %+v: incremental result %#v differs from fresh result %#v",
+				content, edits, nr, fr)
+		}
+		ninfo, nerr := npd.GetFeedback()
+		finfo, ferr := fpd.GetFeedback()
+		if ninfo != finfo || !reflect.DeepEqual(nerr, ferr) {
+			t.Fatalf("content %q, edits %+v: incremental feedback\n%s\n%v\ndiffers from fresh feedback\n%s\n%v",
+				content, edits, ninfo, nerr, finfo, ferr)
+		}
+	}
+	if misses >= freshMisses {
+		t.Errorf("expected less work with incremental parsing, got %d misses (fresh: %d)", misses, freshMisses)
+	}
+}
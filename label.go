@@ -0,0 +1,68 @@
+package gparselib
+
+// ParseLabel calls its subparser and uses the label to describe it in error
+// messages (e.g.: "type name" instead of a regular expression).
+// If the subparser fails at its start position, its errors are replaced by
+// the single error "expected <label>".
+// Errors further into the input are kept because they are more helpful.
+// Everything the subparser expected at its start position is replaced by
+// the label for FurthestError, too.
+// The configuration has to be the label.
+func ParseLabel(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp,
+	cfgLabel string,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindLabel, Name: cfgLabel}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "label '"+cfgLabel+"'"))
+	}
+	orgPos := pd.Source.pos
+	n := 0 // number of expectations from other parsers at the start position
+	if pd.failure != nil && pd.failure.pos == orgPos && pd.failure.src == nil {
+		n = len(pd.failure.expected)
+	}
+
+	pd, ctx = pluginSubparser(pd, ctx)
+
+	if pd.failure != nil && pd.failure.pos == orgPos && pd.failure.src == nil {
+		pd.failure.expected = pd.failure.expected[:n]
+		expect(pd, orgPos, cfgLabel)
+	}
+	if !pd.Result.HasError() || maxErrorPos(pd.Result.Feedback) > orgPos {
+		return pd, ctx
+	}
+
+	feedback := pd.Result.Feedback
+	pd.Result.Feedback = make([]*FeedbackItem, 0, len(feedback))
+	for _, fb := range feedback {
+		if fb.Kind != FeedbackError {
+			pd.Result.Feedback = append(pd.Result.Feedback, fb)
+		}
+	}
+	pe := newParseError(pd, orgPos, "expected "+cfgLabel, nil)
+	pe.Expected = []string{cfgLabel}
+	pd.Result.Feedback = append(pd.Result.Feedback, &FeedbackItem{Pos: orgPos, Kind: FeedbackError, Msg: pe})
+	pd.Result.ErrPos = orgPos
+	return pd, ctx
+}
+
+// NewParseLabelPlugin creates a plugin sporting a parser that describes its
+// subparser with a label.
+func NewParseLabelPlugin(cfgLabel string, pluginSubparser SubparserOp) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseLabel(pd, ctx, pluginSubparser, cfgLabel)
+	}
+}
+
+func maxErrorPos(feedback []*FeedbackItem) int {
+	maxPos := -1
+	for _, fb := range feedback {
+		if fb.Kind == FeedbackError && fb.Pos > maxPos {
+			maxPos = fb.Pos
+		}
+	}
+	return maxPos
+}
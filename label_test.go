@@ -0,0 +1,81 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLabel(t *testing.T) {
+	pRegexp, err := NewParseRegexpPlugin(nil, `[a-zA-Z_][a-zA-Z0-9_]*`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := NewParseLabelPlugin("type name", pRegexp)
+
+	runTests(t, p, []parseTestData{
+		{
+			givenParseData:   newData("match", 1, " int"),
+			expectedResult:   newResult(1, "int", "int", -1),
+			expectedSrcPos:   4,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("no match", 1, " 1nt"),
+			expectedResult:   newResult(1, "", nil, 1),
+			expectedSrcPos:   1,
+			expectedErrCount: 1,
+		},
+	})
+
+	pd, _ := p(NewParseData("test", "1nt"), nil)
+	_, err = pd.GetFeedback()
+	if err == nil || !strings.HasSuffix(err.Error(), "expected type name.") || strings.Contains(err.Error(), "regexp") {
+		t.Errorf("expected only the labeled error, got: %v", err)
+	}
+	expectedMsg := "expected type name; found '1'"
+	if pe := pd.FurthestError(); pe == nil || pe.Msg != expectedMsg {
+		t.Errorf("expected furthest error %q, got: %v", expectedMsg, pe)
+	}
+}
+
+func TestParseLabel_Deeper(t *testing.T) {
+	pCall := NewParseLabelPlugin("call", NewParseAllPlugin([]SubparserOp{
+		NewParseIdentPlugin(nil, "", ""),
+		NewParseLiteralPlugin(nil, "("),
+		NewParseLiteralPlugin(nil, ")"),
+	}, nil))
+
+	pd, _ := pCall(NewParseData("test", "f(x"), nil)
+	if len(pd.Result.Feedback) != 1 || pd.Result.Feedback[0].Pos != 2 ||
+		!strings.Contains(pd.Result.Feedback[0].String(), "Literal ')' expected") {
+		t.Errorf("expected the deeper error to be kept, got: %s", printErrors(pd.Result.Feedback))
+	}
+	expectedMsg := "expected ')'; found 'x'"
+	if pe := pd.FurthestError(); pe == nil || pe.Msg != expectedMsg {
+		t.Errorf("expected furthest error %q, got: %v", expectedMsg, pe)
+	}
+}
+
+func TestParseLabel_Expected(t *testing.T) {
+	pNatural, err := NewParseNaturalPlugin(nil, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := NewParseAnyPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "nil"),
+		NewParseLabelPlugin("number", pNatural),
+		NewParseLabelPlugin("string", NewParseAllPlugin([]SubparserOp{
+			NewParseLiteralPlugin(nil, `"`),
+			NewParseLiteralPlugin(nil, `"`),
+		}, nil)),
+	}, nil)
+
+	pd, _ := p(NewParseData("test", "x"), nil)
+	pe := pd.FurthestError()
+	expectedMsg := "expected one of 'nil', number, string; found 'x'"
+	if pe == nil || pe.Msg != expectedMsg {
+		t.Fatalf("expected furthest error %q, got: %v", expectedMsg, pe)
+	}
+	if len(pe.Expected) != 3 {
+		t.Errorf("expected 3 expectations, got: %q", pe.Expected)
+	}
+}
@@ -0,0 +1,281 @@
+package gparselib
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Lint analyses the grammar statically and reports problems that can be
+// found without parsing:
+//   - repetitions (ParseMulti, ParseStream) of parsers that can match without
+//     consuming input (error: the repetition spins without progress),
+//   - left recursion that isn't handled by ParseLeftRec (error); it only
+//     handles direct left recursion, so cycles through several rules are
+//     always reported,
+//   - end of input followed by parsers that have to consume input (error);
+//     the end of input can be in a referenced rule, too,
+//   - alternatives of ParseAny that are never tried because an earlier
+//     alternative matches a prefix of them (e.g.: "=" before "==") or
+//     always matches (warning),
+//   - rules that can't be reached from the start rule (warning).
+//
+// The feedback is in order of first appearance of the rules and has no
+// position.
+// Parsers that aren't from this package are expected to consume input.
+func (g *Grammar) Lint() []*FeedbackItem {
+	l := &linter{g: g, rg: newRuleGraph(g)}
+	reachable := l.reachable()
+	for _, d := range l.rg.descs {
+		if len(d.Children) == 0 {
+			continue
+		}
+		l.rule = d.Name
+		if !reachable[d.Name] {
+			l.report(FeedbackWarning, "The rule can't be reached from the start rule '"+g.start+"'")
+		}
+		if cycle := l.unhandledLeftRecursion(d.Name); len(cycle) > 2 {
+			l.report(FeedbackError, "The rule is indirectly left recursive but ParseLeftRec only handles "+
+				"direct left recursion: "+strings.Join(cycle, " -> "))
+		} else if cycle != nil {
+			l.report(FeedbackError, "The rule is left recursive without ParseLeftRec: "+strings.Join(cycle, " -> "))
+		}
+		l.lint(d.Children[0], false)
+	}
+	return l.feedback
+}
+
+type linter struct {
+	g        *Grammar
+	rg       *ruleGraph
+	rule     string // current rule
+	feedback []*FeedbackItem
+}
+
+func (l *linter) report(kind FeedbackKind, msg string) {
+	l.feedback = append(l.feedback, &FeedbackItem{Kind: kind, Msg: &parseMessage{rule: l.rule, msg: msg}})
+}
+
+// lint checks the described parser and its subparsers.
+// The flag consumingFollows is true if a parser that has to consume input
+// follows the parser in a sequence.
+func (l *linter) lint(d *Descriptor, consumingFollows bool) {
+	switch d.Kind {
+	case KindEOF:
+		if consumingFollows {
+			l.report(FeedbackError, "The end of input is followed by parsers that have to consume input")
+		}
+	case KindRef:
+		if consumingFollows && l.endsInput(d, nil) {
+			l.report(FeedbackError, "The end of input in rule '"+d.Name+
+				"' is followed by parsers that have to consume input")
+		}
+	case KindMulti, KindStream:
+		if (d.Kind == KindStream || d.Max > 1) && descNullable(d.Children[0], l.rg.nullable) {
+			l.report(FeedbackError, "The repeated parser can match without consuming input: "+d.String())
+		}
+	case KindAny:
+		l.lintAlternatives(d)
+	case KindAll:
+		for i, c := range d.Children {
+			follows := consumingFollows
+			for _, next := range d.Children[i+1:] {
+				follows = follows || !descNullable(next, l.rg.nullable)
+			}
+			l.lint(c, follows)
+		}
+		return
+	case KindNot, KindAnd:
+		consumingFollows = false // predicates don't consume input
+	}
+	for _, c := range d.Children {
+		l.lint(c, consumingFollows)
+	}
+}
+
+// lintAlternatives reports alternatives that are shadowed by an earlier one.
+func (l *linter) lintAlternatives(d *Descriptor) {
+	for j := 1; j < len(d.Children); j++ {
+		for i := 0; i < j; i++ {
+			ti, exact := l.prefix(d.Children[i], nil)
+			if !exact && !l.alwaysMatches(d.Children[i], nil) {
+				continue
+			}
+			tj, _ := l.prefix(d.Children[j], nil)
+			if !strings.HasPrefix(tj, ti) {
+				continue
+			}
+			msg := "Alternative " + strconv.Itoa(j) + " (" + d.Children[j].String() +
+				") is never tried because alternative " + strconv.Itoa(i) + " (" + d.Children[i].String() + ")"
+			if ti == "" {
+				msg += " always matches"
+			} else {
+				msg += " matches its prefix " + quotePEG(ti)
+			}
+			l.report(FeedbackWarning, msg)
+			break
+		}
+	}
+}
+
+// prefix returns the text every match of the described parser starts with.
+// It is exact if the parser always matches input starting with the text and
+// consumes exactly the text.
+func (l *linter) prefix(d *Descriptor, visiting []string) (text string, exact bool) {
+	switch d.Kind {
+	case KindLiteral:
+		return d.Config[0], true
+	case KindCut:
+		return "", true
+	case KindAll:
+		b := &strings.Builder{}
+		for _, c := range d.Children {
+			t, ex := l.prefix(c, visiting)
+			b.WriteString(t)
+			if !ex {
+				return b.String(), false
+			}
+		}
+		return b.String(), true
+	case KindAny:
+		for i, c := range d.Children {
+			t, ex := l.prefix(c, visiting)
+			if i == 0 {
+				text, exact = t, ex
+				continue
+			}
+			text, exact = commonPrefix(text, t), exact && ex && text == t
+		}
+		return text, exact
+	case KindMulti:
+		if d.Min <= 0 {
+			return "", false
+		}
+		text, exact = l.prefix(d.Children[0], visiting)
+		return text, exact && d.Min == 1 && d.Max == 1
+	case KindLabel, KindMemo, KindLeftRec, KindRule:
+		if len(d.Children) == 0 {
+			return "", false
+		}
+		return l.prefix(d.Children[0], visiting)
+	case KindRef:
+		if body := l.ruleBody(d.Name, visiting); body != nil {
+			return l.prefix(body, append(visiting, d.Name))
+		}
+	}
+	return "", false
+}
+
+// alwaysMatches returns true if the described parser matches any input.
+func (l *linter) alwaysMatches(d *Descriptor, visiting []string) bool {
+	switch d.Kind {
+	case KindOptional, KindStream, KindCut:
+		return true
+	case KindLiteral:
+		return d.Config[0] == ""
+	case KindMulti:
+		return d.Min <= 0
+	case KindAll:
+		for _, c := range d.Children {
+			if !l.alwaysMatches(c, visiting) {
+				return false
+			}
+		}
+		return true
+	case KindAny:
+		for _, c := range d.Children {
+			if l.alwaysMatches(c, visiting) {
+				return true
+			}
+		}
+		return false
+	case KindLabel, KindMemo, KindLeftRec, KindRule:
+		return len(d.Children) > 0 && l.alwaysMatches(d.Children[0], visiting)
+	case KindRef:
+		if body := l.ruleBody(d.Name, visiting); body != nil {
+			return l.alwaysMatches(body, append(visiting, d.Name))
+		}
+	}
+	return false
+}
+
+// endsInput returns true if the described parser can match the end of input
+// as its last parser, so the parsers following it can't consume input.
+// The end of input in a sequence before parsers that have to consume input
+// isn't considered because it is reported in its own rule.
+func (l *linter) endsInput(d *Descriptor, visiting []string) bool {
+	switch d.Kind {
+	case KindEOF:
+		return true
+	case KindNot, KindAnd:
+		return false // predicates don't consume input
+	case KindRef:
+		body := l.ruleBody(d.Name, visiting)
+		return body != nil && l.endsInput(body, append(visiting, d.Name))
+	case KindAll:
+		for i := len(d.Children) - 1; i >= 0; i-- {
+			if l.endsInput(d.Children[i], visiting) {
+				return true
+			}
+			if !descNullable(d.Children[i], l.rg.nullable) {
+				return false
+			}
+		}
+		return false
+	}
+	for _, c := range d.Children {
+		if l.endsInput(c, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleBody returns the descriptor of the subparser of the rule or nil if it
+// isn't defined or is visited already (recursion).
+func (l *linter) ruleBody(rule string, visiting []string) *Descriptor {
+	for _, name := range visiting {
+		if name == rule {
+			return nil
+		}
+	}
+	for _, d := range l.rg.descs {
+		if d.Name == rule && len(d.Children) > 0 {
+			return d.Children[0]
+		}
+	}
+	return nil
+}
+
+// reachable returns all rules that can be reached from the start rule.
+func (l *linter) reachable() map[string]bool {
+	reachable := map[string]bool{l.g.start: true}
+	todo := []string{l.g.start}
+	for len(todo) > 0 {
+		name := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		for _, ref := range l.rg.refs[name] {
+			if !reachable[ref] {
+				reachable[ref] = true
+				todo = append(todo, ref)
+			}
+		}
+	}
+	return reachable
+}
+
+// unhandledLeftRecursion returns the shortest cycle of rules that call each
+// other without consuming input starting and ending with the rule (or nil if
+// there is none).
+// Only the direct recursion of a rule wrapped in ParseLeftRec is handled.
+func (l *linter) unhandledLeftRecursion(rule string) []string {
+	body := l.ruleBody(rule, nil)
+	return l.rg.leftCycle(rule, body != nil && body.Kind == KindLeftRec)
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
@@ -0,0 +1,171 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrammar_Lint(t *testing.T) {
+	lit := func(s string) SubparserOp { return NewParseLiteralPlugin(nil, s) }
+	all := func(ps ...SubparserOp) SubparserOp { return NewParseAllPlugin(ps, nil) }
+	anyOf := func(ps ...SubparserOp) SubparserOp { return NewParseAnyPlugin(ps, nil) }
+	pIdent := NewParseIdentPlugin(nil, "", "")
+
+	specs := []struct {
+		name             string
+		givenDefinitions func(g *Grammar)
+		expectedFeedback []string
+	}{
+		{
+			name: "no problems",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("file", all(g.Ref("list"), NewParseEOFPlugin(nil)))
+				g.Define("list", all(
+					g.Ref("item"),
+					NewParseMulti0Plugin(all(lit(","), g.Ref("item")), nil),
+				))
+				g.Define("item", anyOf(lit("=="), lit("="), pIdent, all(lit("("), g.Ref("list"), lit(")"))))
+			},
+		}, {
+			name: "infinite loops",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("list", all(
+					NewParseMulti1Plugin(NewParseOptionalPlugin(lit(","), nil), nil),
+					NewParseMultiPlugin(g.Ref("space"), nil, 0, 1),
+					NewParseMultiPlugin(g.Ref("space"), nil, 0, 2),
+					NewParseStreamPlugin(NewParseNotPlugin(pIdent, nil), nil),
+				))
+				g.Define("space", NewParseMulti0Plugin(lit(" "), nil))
+			},
+			expectedFeedback: []string{
+				"ERROR: In rule 'list': The repeated parser can match without consuming input: (','?)+.",
+				"ERROR: In rule 'list': The repeated parser can match without consuming input: space{0,2}.",
+				"ERROR: In rule 'list': The repeated parser can match without consuming input: (!<identifier>)*.",
+			},
+		}, {
+			name: "left recursion",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("expr", anyOf(all(g.Ref("sum"), lit("!")), g.Ref("value")))
+				g.Define("sum", all(NewParseOptionalPlugin(lit("-"), nil), g.Ref("expr"), lit("+"), g.Ref("value")))
+				g.Define("value", anyOf(pIdent, all(g.Ref("value"), lit("'"))))
+				g.Define("self", NewParseMemoPlugin("self", all(g.Ref("self"), lit("x"))))
+			},
+			expectedFeedback: []string{
+				"ERROR: In rule 'sum': The rule is indirectly left recursive but ParseLeftRec only handles " +
+					"direct left recursion: sum -> expr -> sum.",
+				"ERROR: In rule 'value': The rule is left recursive without ParseLeftRec: value -> value.",
+				"ERROR: In rule 'expr': The rule is indirectly left recursive but ParseLeftRec only handles " +
+					"direct left recursion: expr -> sum -> expr.",
+				"WARNING: In rule 'self': The rule can't be reached from the start rule 'expr'.",
+				"ERROR: In rule 'self': The rule is left recursive without ParseLeftRec: self -> self.",
+			},
+		}, {
+			name: "handled left recursion",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("expr", NewParseLeftRecPlugin("expr", anyOf(all(g.Ref("expr"), lit("+"), pIdent), pIdent)))
+			},
+		}, {
+			name: "handled left recursion through several rules",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("expr", NewParseLeftRecPlugin("expr", anyOf(
+					all(g.Ref("expr"), lit("+"), pIdent),
+					all(g.Ref("sum"), lit("!")),
+					pIdent,
+				)))
+				g.Define("sum", NewParseLeftRecPlugin("sum", all(g.Ref("expr"), lit("-"), pIdent)))
+			},
+			expectedFeedback: []string{
+				"ERROR: In rule 'expr': The rule is indirectly left recursive but ParseLeftRec only handles " +
+					"direct left recursion: expr -> sum -> expr.",
+				"ERROR: In rule 'sum': The rule is indirectly left recursive but ParseLeftRec only handles " +
+					"direct left recursion: sum -> expr -> sum.",
+			},
+		}, {
+			name: "unreachable rules",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("start", g.Ref("used"))
+				g.Define("used", lit("x"))
+				g.Define("unused", g.Ref("unused2"))
+				g.Define("unused2", g.Ref("used"))
+			},
+			expectedFeedback: []string{
+				"WARNING: In rule 'unused2': The rule can't be reached from the start rule 'start'.",
+				"WARNING: In rule 'unused': The rule can't be reached from the start rule 'start'.",
+			},
+		}, {
+			name: "shadowed alternatives",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("op", anyOf(
+					lit("="),
+					lit("=="),
+					all(lit("!"), lit("=")),
+					g.Ref("not equal"),
+					NewParseLabelPlugin("less", anyOf(lit("<"), lit("<"))),
+					lit("<="),
+					NewParseMultiPlugin(lit("<>"), nil, 1, 3),
+					NewParseOptionalPlugin(lit(">"), nil),
+					lit(">="),
+				))
+				g.Define("not equal", all(lit("!="), NewParseNotPlugin(lit("="), nil)))
+			},
+			expectedFeedback: []string{
+				"WARNING: In rule 'op': Alternative 1 ('==') is never tried because alternative 0 ('=') matches its prefix '='.",
+				"WARNING: In rule 'op': Alternative 3 (not equal) is never tried because alternative 2 ('!' '=') matches its prefix '!='.",
+				"WARNING: In rule 'op': Alternative 5 ('<=') is never tried because alternative 4 ('<' / '<') matches its prefix '<'.",
+				"WARNING: In rule 'op': Alternative 6 ('<>'{1,3}) is never tried because alternative 4 ('<' / '<') matches its prefix '<'.",
+				"WARNING: In rule 'op': Alternative 8 ('>=') is never tried because alternative 7 ('>'?) always matches.",
+				"WARNING: In rule 'op': Alternative 1 ('<') is never tried because alternative 0 ('<') matches its prefix '<'.",
+			},
+		}, {
+			name: "end of input",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("file", all(
+					NewParseOptionalPlugin(lit("x"), nil),
+					all(lit("y"), NewParseEOFPlugin(nil)),
+					NewParseOptionalPlugin(lit("z"), nil),
+					NewParseAndPlugin(all(lit("z"), NewParseEOFPlugin(nil)), nil),
+					NewParseEOFPlugin(nil),
+					g.Ref("rest"),
+				))
+				g.Define("rest", lit("!"))
+			},
+			expectedFeedback: []string{
+				"ERROR: In rule 'file': The end of input is followed by parsers that have to consume input.",
+				"ERROR: In rule 'file': The end of input is followed by parsers that have to consume input.",
+			},
+		}, {
+			name: "end of input in referenced rules",
+			givenDefinitions: func(g *Grammar) {
+				g.Define("file", all(g.Ref("header"), g.Ref("body"), lit("!")))
+				g.Define("header", all(lit("h"), anyOf(lit("\n"), g.Ref("end")), NewParseOptionalPlugin(lit(" "), nil)))
+				g.Define("end", NewParseEOFPlugin(nil))
+				g.Define("body", anyOf(all(lit("b"), g.Ref("body")), g.Ref("stop")))
+				g.Define("stop", all(NewParseEOFPlugin(nil), NewParseOptionalPlugin(lit(";"), nil)))
+			},
+			expectedFeedback: []string{
+				"ERROR: In rule 'file': The end of input in rule 'header' is followed by parsers that have to consume input.",
+				"ERROR: In rule 'file': The end of input in rule 'body' is followed by parsers that have to consume input.",
+			},
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			g := NewGrammar()
+			spec.givenDefinitions(g)
+			var got []string
+			for _, fb := range g.Lint() {
+				got = append(got, fb.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(spec.expectedFeedback, "\n") {
+				t.Errorf("expected feedback:\n%s\ngot:\n%s",
+					strings.Join(spec.expectedFeedback, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+
+	// the rules for testing single parsers can't be reached
+	fb := loadCalcGrammar(t).Lint()
+	if len(fb) != 2 || fb[0].Kind != FeedbackWarning || fb[1].Kind != FeedbackWarning {
+		t.Errorf("expected 2 warnings for the calc grammar, got: %v", fb)
+	}
+}
@@ -0,0 +1,148 @@
+package gparselib
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Position is a position as used by the Language Server Protocol (LSP).
+// Line and character start at 0 and the character is counted in UTF-16 code
+// units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a source as used by the Language Server Protocol.
+// The end is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// DiagnosticSeverity is the severity of a diagnostic as defined by the
+// Language Server Protocol.
+type DiagnosticSeverity int
+
+// Enumeration of the severities of diagnostics.
+const (
+	SeverityError       = DiagnosticSeverity(1)
+	SeverityWarning     = DiagnosticSeverity(2)
+	SeverityInformation = DiagnosticSeverity(3)
+	SeverityHint        = DiagnosticSeverity(4)
+)
+
+// Diagnostic is a problem in a source as reported to a Language Server
+// Protocol client.
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Message  string             `json:"message"`
+}
+
+// LSPPosition returns the LSP position of the given byte offset.
+// Offsets outside of the content are moved to its start or end.
+func (sd SourceData) LSPPosition(offset int) Position {
+	offset = max(sd.offset, min(offset, sd.end()))
+	line, start := lineOf(sd.lineIndex(), offset)
+	return Position{Line: sd.firstLine + line, Character: utf16Len(sd.slice(start, offset))}
+}
+
+// LSPOffset returns the byte offset of the given LSP position.
+// As demanded by the LSP specification, a character after the end of the
+// line is moved back to the end of the line.
+// A character in the middle of a surrogate pair gives the offset of the
+// rune.
+// If the line doesn't exist, an error is returned.
+func (sd SourceData) LSPOffset(pos Position) (int, error) {
+	lines := sd.lineIndex()
+	line := pos.Line - sd.firstLine
+	if line < 0 || line >= len(lines) {
+		return 0, fmt.Errorf("line %d is out of range [%d, %d]", pos.Line, sd.firstLine, sd.firstLine+len(lines)-1)
+	}
+	if pos.Character < 0 {
+		return 0, fmt.Errorf("character %d is negative", pos.Character)
+	}
+	start, end := lineBounds(&sd, lines, line)
+	n := 0
+	for i, r := range sd.slice(start, end) {
+		n += utf16RuneLen(r)
+		if n > pos.Character {
+			return start + i, nil
+		}
+	}
+	return end, nil
+}
+
+// Diagnostic converts the feedback item into an LSP diagnostic for the given
+// source.
+// The range covers the character at the position of the feedback.
+func (fi *FeedbackItem) Diagnostic(sd SourceData) Diagnostic {
+	end := min(max(fi.Pos, sd.offset), sd.end())
+	if r, size := utf8.DecodeRuneInString(sd.slice(end, sd.end())); size > 0 && r != '\n' {
+		end += size
+	}
+	return Diagnostic{
+		Range:    Range{Start: sd.LSPPosition(fi.Pos), End: sd.LSPPosition(end)},
+		Severity: fi.Kind.severity(),
+		Message:  fi.plainMessage(),
+	}
+}
+
+// Diagnostics converts all feedback of the result into LSP diagnostics
+// grouped by the name of their source.
+// Feedback of sources included by IncludeSource is grouped by the name of
+// the included source.
+func (pd *ParseData) Diagnostics() map[string][]Diagnostic {
+	diags := make(map[string][]Diagnostic, 1)
+	for _, fb := range pd.Result.Feedback {
+		src, pos := pd.Source.resolve(fb.Pos)
+		item := *fb
+		item.Pos = pos
+		diags[src.Name] = append(diags[src.Name], item.Diagnostic(*src))
+	}
+	return diags
+}
+
+func (fk FeedbackKind) severity() DiagnosticSeverity {
+	switch fk {
+	case FeedbackInfo:
+		return SeverityInformation
+	case FeedbackWarning:
+		return SeverityWarning
+	case FeedbackPotentialProblem:
+		return SeverityHint
+	default:
+		return SeverityError
+	}
+}
+
+// plainMessage returns the message of the feedback item without position
+// because the position is part of the diagnostic already.
+func (fi *FeedbackItem) plainMessage() string {
+	switch msg := fi.Msg.(type) {
+	case *ParseError:
+		if msg.Err != nil {
+			return rulePrefix(msg.Rule) + msg.Msg + ": " + msg.Err.Error()
+		}
+		return rulePrefix(msg.Rule) + msg.Msg
+	case *parseMessage:
+		return rulePrefix(msg.rule) + msg.msg
+	default:
+		return msg.String()
+	}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
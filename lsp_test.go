@@ -0,0 +1,94 @@
+package gparselib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSourceData_LSPPosition(t *testing.T) {
+	// "ä" is 1 UTF-16 code unit (2 bytes), "👍" is 2 code units (4 bytes).
+	sd := NewSourceData("test", "ab\nä👍x\n")
+	specs := []struct {
+		offset   int
+		expected Position
+	}{
+		{offset: 0, expected: Position{Line: 0, Character: 0}},
+		{offset: 2, expected: Position{Line: 0, Character: 2}},
+		{offset: 3, expected: Position{Line: 1, Character: 0}},
+		{offset: 5, expected: Position{Line: 1, Character: 1}},
+		{offset: 9, expected: Position{Line: 1, Character: 3}},
+		{offset: 10, expected: Position{Line: 1, Character: 4}},
+		{offset: 11, expected: Position{Line: 2, Character: 0}},
+	}
+	for _, spec := range specs {
+		actual := sd.LSPPosition(spec.offset)
+		if actual != spec.expected {
+			t.Errorf("offset %d: expected position %+v, got: %+v", spec.offset, spec.expected, actual)
+		}
+		offset, err := sd.LSPOffset(spec.expected)
+		if err != nil {
+			t.Errorf("position %+v: unexpected error: %v", spec.expected, err)
+		} else if offset != spec.offset {
+			t.Errorf("position %+v: expected offset %d, got: %d", spec.expected, spec.offset, offset)
+		}
+	}
+
+	if offset, _ := sd.LSPOffset(Position{Line: 1, Character: 2}); offset != 5 {
+		t.Errorf("expected offset 5 for the middle of a surrogate pair, got: %d", offset)
+	}
+	if offset, _ := sd.LSPOffset(Position{Line: 0, Character: 99}); offset != 2 {
+		t.Errorf("expected offset 2 for a character after the end of the line, got: %d", offset)
+	}
+	if _, err := sd.LSPOffset(Position{Line: 3, Character: 0}); err == nil {
+		t.Errorf("expected an error for a line that doesn't exist")
+	}
+}
+
+func TestParseData_Diagnostics(t *testing.T) {
+	pd := NewParseData("test", "ab\nä👍x\n")
+	pd.Source.pos = 5
+	pd.ruleName = "r"
+	createUnmatchedResult(pd, 0, "Bust", errors.New("base"))
+	pd.AddWarning(10, "Careful")
+	pd.Result.Feedback = addPotentialProblems(pd.Result.Feedback, []*FeedbackItem{
+		{Pos: 0, Kind: FeedbackInfo, Msg: newParseMessage(pd, 0, "Hello")},
+	})
+
+	expected := []Diagnostic{
+		{
+			Range: Range{
+				Start: Position{Line: 1, Character: 1},
+				End:   Position{Line: 1, Character: 3},
+			},
+			Severity: SeverityError,
+			Message:  "In rule 'r': Bust: base",
+		}, {
+			Range: Range{
+				Start: Position{Line: 1, Character: 4},
+				End:   Position{Line: 1, Character: 4},
+			},
+			Severity: SeverityWarning,
+			Message:  "In rule 'r': Careful",
+		}, {
+			Range: Range{
+				Start: Position{Line: 0, Character: 0},
+				End:   Position{Line: 0, Character: 1},
+			},
+			Severity: SeverityInformation,
+			Message:  "In rule 'r': Hello",
+		},
+	}
+	diags := pd.Diagnostics()
+	if len(diags) != 1 {
+		t.Fatalf("expected diagnostics for 1 source, got: %+v", diags)
+	}
+	actual := diags["test"]
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got: %+v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("diagnostic %d: expected %+v, got: %+v", i, expected[i], actual[i])
+		}
+	}
+}
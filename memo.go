@@ -0,0 +1,220 @@
+package gparselib
+
+// MemoStats contains statistics about the usage of a MemoTable.
+type MemoStats struct {
+	Hits   int // number of results served from the table
+	Misses int // number of lookups without a cached result
+	Drops  int // number of results not stored because the table was full
+}
+
+// MemoTable caches the results of memoized subparsers (packrat parsing).
+// Results are keyed by the name of the rule and the source position
+// (global for sources of a SourceSet, so included sources can share it).
+// A MemoTable is opt-in: it is only used if it is set in ParseData.Memo.
+type MemoTable struct {
+	entries     map[memoKey]*memoEntry
+	maxEntries  int
+	stats       MemoStats
+	incremental bool // true if the results should be reused after edits
+}
+
+type memoKey struct {
+	rule string
+	pos  int
+}
+
+type memoEntry struct {
+	result *ParseResult
+	newPos int
+	reach  int // position after the last byte examined for the result
+}
+
+// NewMemoTable creates a new, completely initialized MemoTable.
+// At most maxEntries results are stored.
+// If maxEntries isn't positive the number of results isn't limited.
+func NewMemoTable(maxEntries int) *MemoTable {
+	return &MemoTable{entries: make(map[memoKey]*memoEntry, 128), maxEntries: maxEntries}
+}
+
+// Stats returns the statistics of the memo table.
+func (mt *MemoTable) Stats() MemoStats {
+	return mt.stats
+}
+
+// Len returns the number of results stored in the memo table.
+func (mt *MemoTable) Len() int {
+	return len(mt.entries)
+}
+
+// Reset removes all results from the memo table and clears the statistics.
+func (mt *MemoTable) Reset() {
+	clear(mt.entries)
+	mt.stats = MemoStats{}
+}
+
+// releaseBefore removes all results for positions before pos.
+func (mt *MemoTable) releaseBefore(pos int) {
+	for key := range mt.entries {
+		if key.pos < pos {
+			delete(mt.entries, key)
+		}
+	}
+}
+
+func (mt *MemoTable) lookup(rule string, pos int) *memoEntry {
+	e := mt.entries[memoKey{rule, pos}]
+	if e == nil {
+		mt.stats.Misses++
+	} else {
+		mt.stats.Hits++
+	}
+	return e
+}
+
+func (mt *MemoTable) store(rule string, pos int, result *ParseResult, newPos, reach int) {
+	key := memoKey{rule, pos}
+	if _, ok := mt.entries[key]; !ok && mt.maxEntries > 0 && len(mt.entries) >= mt.maxEntries {
+		mt.stats.Drops++
+		return
+	}
+	mt.put(key, result, newPos, reach)
+}
+
+// put stores the result even if the memo table is full.
+// The stored entry is returned.
+func (mt *MemoTable) put(key memoKey, result *ParseResult, newPos, reach int) *memoEntry {
+	e := &memoEntry{result: copyResult(result), newPos: newPos, reach: reach}
+	mt.entries[key] = e
+	return e
+}
+
+// ParseMemo calls its subparser only if no result is cached for the rule
+// at the current position.
+// The configuration has to be the name of the rule; it has to be unique
+// for the subparser.
+// Results are only cached if pd.Memo is set.
+// The subparser must not depend on or change the context since it isn't cached.
+func ParseMemo(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp,
+	cfgRule string,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeNamed(pd, ctx, &Descriptor{Kind: KindMemo, Name: cfgRule}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "memo '"+cfgRule+"'"))
+	}
+	if pd.Memo == nil {
+		return pluginSubparser(pd, ctx)
+	}
+	orgPos := pd.Source.pos
+	if e := pd.Memo.lookup(cfgRule, pd.Source.base+orgPos); e != nil {
+		pd.Result = copyResult(e.result)
+		pd.Source.pos = e.newPos
+		pd.Source.touch(e.reach)
+		return pd, ctx
+	}
+
+	orgReach := pd.Source.reach
+	pd.Source.reach = orgPos
+	pd, ctx = pluginSubparser(pd, ctx)
+	pd.Memo.store(cfgRule, pd.Source.base+orgPos, pd.Result, pd.Source.pos, pd.Source.reach)
+	pd.Source.touch(orgReach)
+	return pd, ctx
+}
+
+// NewParseMemoPlugin creates a plugin sporting a parser caching the results
+// of its subparser.
+func NewParseMemoPlugin(cfgRule string, pluginSubparser SubparserOp) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseMemo(pd, ctx, pluginSubparser, cfgRule)
+	}
+}
+
+// copyResult copies the result so parent parsers can change it without
+// changing the cached original.
+func copyResult(pr *ParseResult) *ParseResult {
+	cp := *pr
+	cp.Feedback = append(make([]*FeedbackItem, 0, len(pr.Feedback)), pr.Feedback...)
+	return &cp
+}
+
+// ParseLeftRec calls its left recursive subparser until its match doesn't
+// grow anymore (seed growing).
+// So rules like `expr <- expr '+' term / term` can be used directly.
+// The configuration has to be the name of the rule; it has to be unique
+// for the subparser.
+// The subparser has to reference this parser (not the subparser itself) for
+// the recursion.
+// Only direct left recursion is supported and rules between this parser and
+// its left recursive reference must not be memoized.
+// If pd.Memo isn't set, the seeds are kept in a private table and the final
+// result isn't memoized.
+func ParseLeftRec(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp,
+	cfgRule string,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeNamed(pd, ctx, &Descriptor{Kind: KindLeftRec, Name: cfgRule}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "left recursion '"+cfgRule+"'"))
+	}
+	memo := pd.Memo
+	if memo == nil {
+		if pd.seeds == nil {
+			pd.seeds = NewMemoTable(0)
+		}
+		memo = pd.seeds
+	}
+	orgPos := pd.Source.pos
+	key := memoKey{cfgRule, pd.Source.base + orgPos}
+	if e := memo.lookup(key.rule, key.pos); e != nil {
+		pd.Result = copyResult(e.result)
+		pd.Source.pos = e.newPos
+		pd.Source.touch(e.reach)
+		return pd, ctx
+	}
+	if memo == pd.seeds { // the final result isn't memoized
+		defer delete(memo.entries, key)
+	}
+
+	// plant the seed: the left recursive call has to fail at first
+	orgReach := pd.Source.reach
+	pd.Source.reach = orgPos
+	createUnmatchedResult(pd, 0, "Left recursion of rule '"+cfgRule+"' without a seed", nil)
+	best := memo.put(key, pd.Result, orgPos, orgPos)
+	grown := false
+	for {
+		pd.Source.pos = orgPos
+		pd.Result = nil
+		pd.backtracks++
+		pd, ctx = pluginSubparser(pd, ctx)
+		pd.backtracks--
+		if pd.Result.HasError() || pd.Source.pos <= best.newPos {
+			break
+		}
+		grown = true
+		best = memo.put(key, pd.Result, pd.Source.pos, orgPos)
+	}
+
+	if !grown || pd.Result.HardError {
+		best = memo.put(key, pd.Result, pd.Source.pos, orgPos)
+	}
+	best.reach = pd.Source.reach // reached by all rounds together
+	memo.entries[key] = best     // the subparser might have released it
+	pd.Result = copyResult(best.result)
+	pd.Source.pos = best.newPos
+	pd.Source.touch(orgReach)
+	return pd, ctx
+}
+
+// NewParseLeftRecPlugin creates a plugin sporting a parser growing the match
+// of its left recursive subparser.
+func NewParseLeftRecPlugin(cfgRule string, pluginSubparser SubparserOp) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseLeftRec(pd, ctx, pluginSubparser, cfgRule)
+	}
+}
@@ -0,0 +1,216 @@
+package gparselib
+
+import (
+	"testing"
+)
+
+func TestParseMemo(t *testing.T) {
+	calls := 0
+	plFlow := NewParseLiteralPlugin(nil, "flow")
+	counter := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		calls++
+		return plFlow(pd, ctx)
+	}
+	pm := NewParseMemoPlugin("flow", counter)
+	p := NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{pm, NewParseLiteralPlugin(nil, "no")}, nil),
+		NewParseAllPlugin([]SubparserOp{pm, NewParseLiteralPlugin(nil, "yes")}, nil),
+		NewParseOptionalPlugin(pm, nil),
+	}, nil)
+
+	specs := []struct {
+		name          string
+		givenMemo     *MemoTable
+		givenContent  string
+		expectedCalls int
+		expectedStats MemoStats
+	}{
+		{
+			name:          "no memo table",
+			givenMemo:     nil,
+			givenContent:  "flowyes",
+			expectedCalls: 2,
+		}, {
+			name:          "match 2nd",
+			givenMemo:     NewMemoTable(0),
+			givenContent:  "flowyes",
+			expectedCalls: 1,
+			expectedStats: MemoStats{Hits: 1, Misses: 1},
+		}, {
+			name:          "match optional",
+			givenMemo:     NewMemoTable(0),
+			givenContent:  "flowmaybe",
+			expectedCalls: 1,
+			expectedStats: MemoStats{Hits: 2, Misses: 1},
+		}, {
+			name:          "no match",
+			givenMemo:     NewMemoTable(0),
+			givenContent:  "nothing",
+			expectedCalls: 1,
+			expectedStats: MemoStats{Hits: 2, Misses: 1},
+		}, {
+			name:          "unlimited memo table",
+			givenMemo:     NewMemoTable(-1),
+			givenContent:  "flowmaybe",
+			expectedCalls: 1,
+			expectedStats: MemoStats{Hits: 2, Misses: 1},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.name, func(tt *testing.T) {
+			calls = 0
+			pd := NewParseData(spec.name, spec.givenContent)
+			pd.Memo = spec.givenMemo
+			pd, _ = p(pd, nil)
+			if calls != spec.expectedCalls {
+				tt.Errorf("expected %d calls of the subparser, got: %d", spec.expectedCalls, calls)
+			}
+			if pd.Memo != nil && pd.Memo.Stats() != spec.expectedStats {
+				tt.Errorf("expected memo stats %#v, got: %#v", spec.expectedStats, pd.Memo.Stats())
+			}
+		})
+	}
+}
+
+func TestParseMemo_Results(t *testing.T) {
+	pm := NewParseMemoPlugin("flow", NewParseLiteralPlugin(nil, "flow"))
+	p := NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{pm, NewParseLiteralPlugin(nil, "no")}, nil),
+		NewParseAllPlugin([]SubparserOp{NewParseOptionalPlugin(pm, nil), pm}, nil),
+	}, nil)
+
+	runTests(t, p, []parseTestData{
+		{
+			givenParseData:   newMemoData("empty", 0, ""),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 3,
+		}, {
+			givenParseData:   newMemoData("match 1st", 2, "12flowno"),
+			expectedResult:   newResult(2, "flowno", []interface{}{nil, nil}, -1),
+			expectedSrcPos:   8,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newMemoData("match 2nd", 0, "flowflow"),
+			expectedResult:   newResult(0, "flowflow", []interface{}{nil, nil}, -1),
+			expectedSrcPos:   8,
+			expectedErrCount: 0,
+		},
+	})
+}
+
+func TestMemoTable_Limit(t *testing.T) {
+	mt := NewMemoTable(2)
+	pr := &ParseResult{Pos: 0, Text: "a", ErrPos: -1}
+	for i := 0; i < 4; i++ {
+		mt.store("rule", i, pr, i+1, i+1)
+	}
+	mt.store("rule", 1, pr, 2, 2) // replacing is always possible
+	if mt.Len() != 2 {
+		t.Errorf("expected 2 entries, got: %d", mt.Len())
+	}
+	if mt.Stats().Drops != 2 {
+		t.Errorf("expected 2 drops, got: %d", mt.Stats().Drops)
+	}
+	if mt.lookup("rule", 3) != nil {
+		t.Errorf("didn't expect a result for position 3")
+	}
+	mt.Reset()
+	if mt.Len() != 0 || mt.Stats() != (MemoStats{}) {
+		t.Errorf("expected empty memo table after reset, got %d entries and stats: %#v", mt.Len(), mt.Stats())
+	}
+}
+
+func newMemoData(srcName string, srcPos int, srcContent string) *ParseData {
+	pd := newData(srcName, srcPos, srcContent)
+	pd.Memo = NewMemoTable(0)
+	return pd
+}
+
+func TestParseLeftRec(t *testing.T) {
+	var expr SubparserOp
+	exprRef := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return expr(pd, ctx)
+	}
+	num, err := NewParseNaturalPlugin(func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		pd.Result.Value = int(pd.Result.Value.(uint64))
+		return pd, ctx
+	}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	minus := NewParseAllPlugin(
+		[]SubparserOp{exprRef, NewParseLiteralPlugin(nil, "-"), num},
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = pd.SubResults[0].Value.(int) - pd.SubResults[2].Value.(int)
+			return pd, ctx
+		},
+	)
+	expr = NewParseLeftRecPlugin("expr", NewParseAnyPlugin([]SubparserOp{minus, num}, nil))
+
+	runTests(t, expr, []parseTestData{
+		{
+			givenParseData:   newData("empty", 0, ""),
+			expectedResult:   newResult(0, "", nil, 0),
+			expectedSrcPos:   0,
+			expectedErrCount: 3,
+		}, {
+			givenParseData:   newData("seed only", 0, "7"),
+			expectedResult:   newResult(0, "7", 7, -1),
+			expectedSrcPos:   1,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("left associative", 0, "10-3-2"),
+			expectedResult:   newResult(0, "10-3-2", 5, -1),
+			expectedSrcPos:   6,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("incomplete", 2, "1+10-3-"),
+			expectedResult:   newResult(2, "10-3", 7, -1),
+			expectedSrcPos:   6,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newMemoData("with memo table", 0, "9-8"),
+			expectedResult:   newResult(0, "9-8", 1, -1),
+			expectedSrcPos:   3,
+			expectedErrCount: 0,
+		},
+	})
+}
+
+func TestParseLeftRec_Memo(t *testing.T) {
+	var expr SubparserOp
+	exprRef := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return expr(pd, ctx)
+	}
+	resetMemo := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		if pd.Memo != nil {
+			pd.Memo.Reset()
+		}
+		return pd, ctx
+	}
+	a := NewParseLiteralPlugin(nil, "a")
+	expr = NewParseLeftRecPlugin("expr", NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{exprRef, NewParseLiteralPlugin(resetMemo, "+"), a}, nil),
+		a,
+	}, nil))
+
+	pd, _ := expr(NewParseData("no memo table", "a+a+a"), nil)
+	if pd.Result.HasError() || pd.Source.pos != 5 {
+		t.Errorf("expected to match everything, got position %d: %s", pd.Source.pos, printErrors(pd.Result.Feedback))
+	}
+	if pd.Memo != nil || len(pd.seeds.entries) != 0 {
+		t.Errorf("expected no memo table and no seeds to be left over, got: %v, %v", pd.Memo, pd.seeds.entries)
+	}
+
+	pd = NewParseData("memo table reset", "a+a+a")
+	pd.Memo = NewMemoTable(0)
+	pd, _ = expr(pd, nil)
+	if pd.Result.HasError() || pd.Source.pos != 5 {
+		t.Errorf("expected to match everything, got position %d: %s", pd.Source.pos, printErrors(pd.Result.Feedback))
+	}
+	if pd.Memo.Len() != 1 {
+		t.Errorf("expected the final result to be memoized, got %d results", pd.Memo.Len())
+	}
+}
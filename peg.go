@@ -0,0 +1,492 @@
+package gparselib
+
+import (
+	"slices"
+	"strings"
+)
+
+// LoadGrammar loads a grammar written in PEG notation and builds it with the
+// parsers of this package.
+// The grammar is a list of rule definitions (`rule <- a b / c*`).
+// Expressions can be rule names, literals ('x' or "x"), character classes
+// ([a-z]), any character (.), groups (( )), predicates (&e, !e) and
+// quantifiers (e?, e*, e+).
+// Comments start with '#' and go until the end of the line.
+// A sequence can be followed by the name of a semantic action in braces
+// ({name}); the action is looked up in the given actions and gets the values
+// of the sequence elements as subresults.
+// Left recursive rules are detected and wrapped with ParseLeftRec, all other
+// rules are wrapped with ParseMemo.
+// Indirect left recursion (e.g.: a <- b 'x'; b <- a 'y') is an error.
+// The first rule is the start rule of the grammar.
+// Problems in the grammar are reported with positions in the grammar text
+// just like GetFeedback does.
+func LoadGrammar(name, text string, actions map[string]SemanticsOp) (*Grammar, string, error) {
+	pd, rules := parsePEG(name, text)
+	if rules == nil {
+		fb, err := pd.GetFeedback()
+		return nil, fb, err
+	}
+
+	b := &pegBuilder{pd: pd, g: NewGrammar(), actions: actions}
+	b.buildRules(rules)
+	fb, err := pd.GetFeedback()
+	if err != nil {
+		return nil, fb, err
+	}
+	return b.g, fb, nil
+}
+
+// pegKind is just an enumeration of kinds of PEG expressions.
+type pegKind int
+
+// Enumeration of the kinds of PEG expressions.
+const (
+	pegLiteral = pegKind(iota)
+	pegClass
+	pegAnyChar
+	pegRef
+	pegSeq
+	pegChoice
+	pegMulti0
+	pegMulti1
+	pegOptional
+	pegNot
+	pegAnd
+)
+
+// pegExpr is a node of the syntax tree of a PEG grammar.
+type pegExpr struct {
+	kind     pegKind
+	pos      int    // position in the grammar text
+	text     string // literal, regular expression or rule name
+	action   string // name of the semantic action (sequences only)
+	actPos   int    // position of the semantic action in the grammar text
+	children []*pegExpr
+}
+
+// String returns the expression in PEG notation.
+func (e *pegExpr) String() string {
+	b := &strings.Builder{}
+	writePEG(b, e, 0)
+	return b.String()
+}
+
+// writePEG writes the expression in PEG notation.
+// Parentheses are written if the precedence of the expression is lower than
+// the given one (choice: 0, sequence: 1, prefix: 2, suffix: 3, primary: 4).
+func writePEG(b *strings.Builder, e *pegExpr, prec int) {
+	open := func(p int) {
+		if prec > p {
+			b.WriteRune('(')
+		}
+	}
+	closing := func(p int) {
+		if prec > p {
+			b.WriteRune(')')
+		}
+	}
+	switch e.kind {
+	case pegLiteral:
+		b.WriteString(quotePEG(e.text))
+	case pegClass, pegRef:
+		b.WriteString(e.text)
+	case pegAnyChar:
+		b.WriteRune('.')
+	case pegChoice:
+		open(0)
+		for i, c := range e.children {
+			if i > 0 {
+				b.WriteString(" / ")
+			}
+			writePEG(b, c, 1)
+		}
+		closing(0)
+	case pegSeq:
+		open(1)
+		for i, c := range e.children {
+			if i > 0 {
+				b.WriteRune(' ')
+			}
+			writePEG(b, c, 2)
+		}
+		if e.action != "" {
+			b.WriteString(" {" + e.action + "}")
+		}
+		closing(1)
+	case pegNot, pegAnd:
+		open(2)
+		if e.kind == pegNot {
+			b.WriteRune('!')
+		} else {
+			b.WriteRune('&')
+		}
+		writePEG(b, e.children[0], 3)
+		closing(2)
+	default: // pegMulti0, pegMulti1, pegOptional
+		open(3)
+		writePEG(b, e.children[0], 4)
+		switch e.kind {
+		case pegMulti0:
+			b.WriteRune('*')
+		case pegMulti1:
+			b.WriteRune('+')
+		default:
+			b.WriteRune('?')
+		}
+		closing(3)
+	}
+}
+
+func quotePEG(s string) string {
+	b := strings.Builder{}
+	b.WriteRune('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune('\'')
+	return b.String()
+}
+
+// pegRule is a rule definition of a PEG grammar.
+type pegRule struct {
+	name string
+	pos  int
+	expr *pegExpr
+}
+
+// parsePEG parses the grammar text.
+// The returned rules are nil if the text isn't a valid grammar.
+func parsePEG(name, text string) (*ParseData, []*pegRule) {
+	pd := NewParseData(name, text)
+	pd, _ = newPEGParser()(pd, nil)
+	if pd.Result.HasError() {
+		return pd, nil
+	}
+	pd.CleanFeedback(true)
+	return pd, pd.Result.Value.([]*pegRule)
+}
+
+// newPEGParser creates the parser for PEG grammars:
+//
+//	grammar    <- spacing definition+ EOF
+//	definition <- identifier '<-' expression
+//	expression <- sequence ('/' sequence)*
+//	sequence   <- prefix+ action?
+//	action     <- '{' identifier '}'
+//	prefix     <- ('&' / '!')? suffix
+//	suffix     <- primary ('?' / '*' / '+')?
+//	primary    <- identifier !'<-' / '(' expression ')' / literal / class / '.'
+func newPEGParser() SubparserOp {
+	g := NewGrammar()
+	spacing := NewParseMulti0Plugin(NewParseAnyPlugin([]SubparserOp{
+		NewParseSpacePlugin(nil, true),
+		pegRegexp(nil, `#[^\n]*`),
+	}, nil), nil)
+	token := func(pluginSubparser SubparserOp) SubparserOp {
+		return NewParseAllPlugin([]SubparserOp{pluginSubparser, spacing}, semanticsFirstValue)
+	}
+	literal := func(cfgLiteral string) SubparserOp {
+		return token(NewParseLiteralPlugin(semanticsText, cfgLiteral))
+	}
+	identifier := token(pegRegexp(nil, `[a-zA-Z_][a-zA-Z0-9_]*`))
+
+	g.Define("grammar", NewParseAllPlugin([]SubparserOp{
+		spacing,
+		NewParseMulti1Plugin(g.Ref("definition"), nil),
+		NewParseEOFPlugin(nil),
+	}, semanticsPEGGrammar))
+	g.Define("definition", NewParseAllPlugin([]SubparserOp{
+		identifier, literal("<-"), g.Ref("expression"),
+	}, semanticsPEGDefinition))
+	g.Define("expression", NewParseAllPlugin([]SubparserOp{
+		g.Ref("sequence"),
+		NewParseMulti0Plugin(NewParseAllPlugin([]SubparserOp{literal("/"), g.Ref("sequence")}, nil), nil),
+	}, semanticsPEGExpression))
+	g.Define("sequence", NewParseAllPlugin([]SubparserOp{
+		NewParseMulti1Plugin(g.Ref("prefix"), nil),
+		NewParseOptionalPlugin(g.Ref("action"), nil),
+	}, semanticsPEGSequence))
+	g.Define("action", NewParseAllPlugin([]SubparserOp{
+		literal("{"), identifier, literal("}"),
+	}, semanticsPEGAction))
+	g.Define("prefix", NewParseAllPlugin([]SubparserOp{
+		NewParseOptionalPlugin(NewParseAnyPlugin([]SubparserOp{literal("&"), literal("!")}, nil), nil),
+		g.Ref("suffix"),
+	}, semanticsPEGPrefix))
+	g.Define("suffix", NewParseAllPlugin([]SubparserOp{
+		g.Ref("primary"),
+		NewParseOptionalPlugin(NewParseAnyPlugin([]SubparserOp{literal("?"), literal("*"), literal("+")}, nil), nil),
+	}, semanticsPEGSuffix))
+	g.Define("primary", NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{
+			identifier, NewParseNotPlugin(literal("<-"), nil),
+		}, semanticsPEGRef),
+		NewParseAllPlugin([]SubparserOp{
+			literal("("), g.Ref("expression"), literal(")"),
+		}, semanticsSecondValue),
+		token(pegRegexp(semanticsPEGLiteral, `(?:'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*")`)),
+		token(pegRegexp(semanticsPEGClass, `\[(?:[^\]\\]|\\.)*\]`)),
+		token(NewParseLiteralPlugin(semanticsPEGAnyChar, ".")),
+	}, nil))
+	return g.Start()
+}
+
+// pegRegexp creates a regular expression parser for a constant (and valid)
+// regular expression.
+func pegRegexp(pluginSemantics SemanticsOp, cfgRegexp string) SubparserOp {
+	p, err := NewParseRegexpPlugin(pluginSemantics, cfgRegexp)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func semanticsText(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = pd.Result.Text
+	return pd, ctx
+}
+func semanticsFirstValue(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = pd.SubResults[0].Value
+	return pd, ctx
+}
+func semanticsSecondValue(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = pd.SubResults[1].Value
+	return pd, ctx
+}
+func semanticsPEGGrammar(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	defs := pd.SubResults[1].Value.([]interface{})
+	rules := make([]*pegRule, len(defs))
+	for i, def := range defs {
+		rules[i] = def.(*pegRule)
+	}
+	pd.Result.Value = rules
+	return pd, ctx
+}
+func semanticsPEGDefinition(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = &pegRule{
+		name: pd.SubResults[0].Value.(string),
+		pos:  pd.SubResults[0].Pos,
+		expr: pd.SubResults[2].Value.(*pegExpr),
+	}
+	return pd, ctx
+}
+func semanticsPEGExpression(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	first := pd.SubResults[0].Value.(*pegExpr)
+	others := pd.SubResults[1].Value.([]interface{})
+	if len(others) == 0 {
+		pd.Result.Value = first
+		return pd, ctx
+	}
+	choice := &pegExpr{kind: pegChoice, pos: pd.Result.Pos, children: []*pegExpr{first}}
+	for _, other := range others {
+		choice.children = append(choice.children, other.([]interface{})[1].(*pegExpr))
+	}
+	pd.Result.Value = choice
+	return pd, ctx
+}
+func semanticsPEGSequence(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	prefixes := pd.SubResults[0].Value.([]interface{})
+	action, _ := pd.SubResults[1].Value.(*pegExpr)
+	if len(prefixes) == 1 && action == nil {
+		pd.Result.Value = prefixes[0]
+		return pd, ctx
+	}
+	seq := &pegExpr{kind: pegSeq, pos: pd.Result.Pos, children: make([]*pegExpr, len(prefixes))}
+	for i, prefix := range prefixes {
+		seq.children[i] = prefix.(*pegExpr)
+	}
+	if action != nil {
+		seq.action = action.text
+		seq.actPos = action.pos
+	}
+	pd.Result.Value = seq
+	return pd, ctx
+}
+func semanticsPEGAction(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = &pegExpr{pos: pd.SubResults[1].Pos, text: pd.SubResults[1].Value.(string)}
+	return pd, ctx
+}
+func semanticsPEGPrefix(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	suffix := pd.SubResults[1].Value.(*pegExpr)
+	switch pd.SubResults[0].Value {
+	case "&":
+		suffix = &pegExpr{kind: pegAnd, pos: pd.Result.Pos, children: []*pegExpr{suffix}}
+	case "!":
+		suffix = &pegExpr{kind: pegNot, pos: pd.Result.Pos, children: []*pegExpr{suffix}}
+	}
+	pd.Result.Value = suffix
+	return pd, ctx
+}
+func semanticsPEGSuffix(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	primary := pd.SubResults[0].Value.(*pegExpr)
+	switch pd.SubResults[1].Value {
+	case "?":
+		primary = &pegExpr{kind: pegOptional, pos: pd.Result.Pos, children: []*pegExpr{primary}}
+	case "*":
+		primary = &pegExpr{kind: pegMulti0, pos: pd.Result.Pos, children: []*pegExpr{primary}}
+	case "+":
+		primary = &pegExpr{kind: pegMulti1, pos: pd.Result.Pos, children: []*pegExpr{primary}}
+	}
+	pd.Result.Value = primary
+	return pd, ctx
+}
+func semanticsPEGRef(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = &pegExpr{kind: pegRef, pos: pd.Result.Pos, text: pd.SubResults[0].Value.(string)}
+	return pd, ctx
+}
+func semanticsPEGLiteral(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	text := pd.Result.Text
+	pd.Result.Value = &pegExpr{kind: pegLiteral, pos: pd.Result.Pos, text: unescapePEG(text[1 : len(text)-1])}
+	return pd, ctx
+}
+func semanticsPEGClass(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = &pegExpr{kind: pegClass, pos: pd.Result.Pos, text: pd.Result.Text}
+	return pd, ctx
+}
+func semanticsPEGAnyChar(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+	pd.Result.Value = &pegExpr{kind: pegAnyChar, pos: pd.Result.Pos}
+	return pd, ctx
+}
+
+func unescapePEG(s string) string {
+	if !strings.ContainsRune(s, '\\') {
+		return s
+	}
+	b := strings.Builder{}
+	afterBackslash := false
+	for _, r := range s {
+		switch {
+		case afterBackslash:
+			afterBackslash = false
+			switch r {
+			case 'n':
+				r = '\n'
+			case 'r':
+				r = '\r'
+			case 't':
+				r = '\t'
+			}
+			b.WriteRune(r)
+		case r == '\\':
+			afterBackslash = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pegBuilder builds the parsers for the rules of a PEG grammar.
+type pegBuilder struct {
+	pd            *ParseData
+	g             *Grammar
+	actions       map[string]SemanticsOp
+	ignoreActions bool // semantic actions are bound later (generated code)
+	rules         map[string]*pegRule
+	leftRec       map[string]bool // rules wrapped with ParseLeftRec
+}
+
+func (b *pegBuilder) buildRules(rules []*pegRule) {
+	b.rules = make(map[string]*pegRule, len(rules))
+	for _, r := range rules {
+		if _, ok := b.rules[r.name]; ok {
+			b.pd.AddError(r.pos, "Rule '"+r.name+"' is defined multiple times", nil)
+			continue
+		}
+		b.rules[r.name] = r
+	}
+	var unique []*pegRule
+	var bodies []SubparserOp
+	var descs []*Descriptor
+	for _, r := range rules {
+		if b.rules[r.name] == r {
+			p := b.build(r.expr)
+			b.g.rule(r.name) // the order of the rules is the same as with Define
+			unique = append(unique, r)
+			bodies = append(bodies, p)
+			descs = append(descs, &Descriptor{Kind: KindRule, Name: r.name, Children: []*Descriptor{Describe(p)}})
+		}
+	}
+
+	// ParseLeftRec only supports direct left recursion
+	rg := newDescribedRuleGraph(descs)
+	b.leftRec = make(map[string]bool, len(unique))
+	for i, r := range unique {
+		if cycle := rg.leftCycle(r.name, true); cycle != nil {
+			b.pd.AddError(r.pos, "Rule '"+r.name+"' is indirectly left recursive ("+
+				strings.Join(cycle, " -> ")+") but only direct left recursion is supported", nil)
+		}
+		if slices.Contains(rg.leftRefs[r.name], r.name) {
+			b.leftRec[r.name] = true
+			b.g.Define(r.name, NewParseLeftRecPlugin(r.name, bodies[i]))
+		} else {
+			b.g.Define(r.name, NewParseMemoPlugin(r.name, bodies[i]))
+		}
+	}
+}
+
+func (b *pegBuilder) build(e *pegExpr) SubparserOp {
+	switch e.kind {
+	case pegLiteral:
+		return NewParseLiteralPlugin(nil, e.text)
+	case pegClass:
+		return b.buildRegexp(e, e.text)
+	case pegAnyChar:
+		return b.buildRegexp(e, `(?s).`)
+	case pegRef:
+		if _, ok := b.rules[e.text]; !ok {
+			b.pd.AddError(e.pos, "Rule '"+e.text+"' isn't defined", nil)
+		}
+		return b.g.Ref(e.text)
+	case pegSeq:
+		var semantics SemanticsOp
+		if e.action != "" && !b.ignoreActions {
+			semantics = b.actions[e.action]
+			if semantics == nil {
+				b.pd.AddError(e.actPos, "Semantic action '"+e.action+"' isn't defined", nil)
+			}
+		}
+		return NewParseAllPlugin(b.buildAll(e.children), semantics)
+	case pegChoice:
+		return NewParseAnyPlugin(b.buildAll(e.children), nil)
+	case pegMulti0:
+		return NewParseMulti0Plugin(b.build(e.children[0]), nil)
+	case pegMulti1:
+		return NewParseMulti1Plugin(b.build(e.children[0]), nil)
+	case pegOptional:
+		return NewParseOptionalPlugin(b.build(e.children[0]), nil)
+	case pegNot:
+		return NewParseNotPlugin(b.build(e.children[0]), nil)
+	default: // pegAnd
+		return NewParseAndPlugin(b.build(e.children[0]), nil)
+	}
+}
+
+func (b *pegBuilder) buildAll(es []*pegExpr) []SubparserOp {
+	ps := make([]SubparserOp, len(es))
+	for i, e := range es {
+		ps[i] = b.build(e)
+	}
+	return ps
+}
+
+func (b *pegBuilder) buildRegexp(e *pegExpr, cfgRegexp string) SubparserOp {
+	p, err := NewParseRegexpPlugin(nil, cfgRegexp)
+	if err != nil {
+		b.pd.AddError(e.pos, "Invalid character class "+e.text, err)
+	}
+	return p
+}
@@ -0,0 +1,190 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+const pegTestGrammar = `# simple calculator
+expr    <- expr '+' term {add}
+         / expr '-' term {sub}
+         / term
+term    <- number / '(' expr ')' {paren}
+number  <- [0-9]+ {number}
+keyword <- ("if" / "else") ![a-z]
+any     <- &"a" . .? {text}
+`
+
+func TestLoadGrammar(t *testing.T) {
+	toInt := func(v interface{}) int {
+		return v.(int)
+	}
+	actions := map[string]SemanticsOp{
+		"add": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = toInt(pd.SubResults[0].Value) + toInt(pd.SubResults[2].Value)
+			return pd, ctx
+		},
+		"sub": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			pd.Result.Value = toInt(pd.SubResults[0].Value) - toInt(pd.SubResults[2].Value)
+			return pd, ctx
+		},
+		"paren": semanticsSecondValue,
+		"number": func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			n := 0
+			for _, c := range pd.Result.Text {
+				n = n*10 + int(c-'0')
+			}
+			pd.Result.Value = n
+			return pd, ctx
+		},
+		"text": semanticsText,
+	}
+	g, fb, err := LoadGrammar("calc.peg", pegTestGrammar, actions)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if fb != "" {
+		t.Errorf("expected no feedback, got: %q", fb)
+	}
+
+	t.Run("expr", func(tt *testing.T) {
+		runTests(tt, g.Start(), []parseTestData{
+			{
+				givenParseData:   newData("empty", 0, ""),
+				expectedResult:   newResult(0, "", nil, 0),
+				expectedSrcPos:   0,
+				expectedErrCount: 7,
+			}, {
+				givenParseData:   newData("number", 0, "42"),
+				expectedResult:   newResult(0, "42", 42, -1),
+				expectedSrcPos:   2,
+				expectedErrCount: 0,
+			}, {
+				givenParseData:   newMemoData("calculation", 0, "10-(2+3)-1+7"),
+				expectedResult:   newResult(0, "10-(2+3)-1+7", 11, -1),
+				expectedSrcPos:   12,
+				expectedErrCount: 0,
+			},
+		})
+	})
+	t.Run("keyword", func(tt *testing.T) {
+		runTests(tt, g.Ref("keyword"), []parseTestData{
+			{
+				givenParseData:   newData("keyword", 0, "else"),
+				expectedResult:   newResult(0, "else", []interface{}{nil, nil}, -1),
+				expectedSrcPos:   4,
+				expectedErrCount: 0,
+			}, {
+				givenParseData:   newData("identifier", 0, "iffy"),
+				expectedResult:   newResult(0, "", nil, 2),
+				expectedSrcPos:   0,
+				expectedErrCount: 1,
+			},
+		})
+	})
+	t.Run("any", func(tt *testing.T) {
+		runTests(tt, g.Ref("any"), []parseTestData{
+			{
+				givenParseData:   newData("one", 0, "a"),
+				expectedResult:   newResult(0, "a", "a", -1),
+				expectedSrcPos:   1,
+				expectedErrCount: 0,
+			}, {
+				givenParseData:   newData("two", 0, "aö"),
+				expectedResult:   newResult(0, "aö", "aö", -1),
+				expectedSrcPos:   3,
+				expectedErrCount: 0,
+			}, {
+				givenParseData:   newData("wrong start", 0, "ba"),
+				expectedResult:   newResult(0, "", nil, 0),
+				expectedSrcPos:   0,
+				expectedErrCount: 2,
+			},
+		})
+	})
+}
+
+func TestLoadGrammar_Errors(t *testing.T) {
+	specs := []struct {
+		name             string
+		givenGrammar     string
+		expectedMessages []string
+	}{
+		{
+			name:             "empty",
+			givenGrammar:     "",
+			expectedMessages: []string{"line 1, column 1"},
+		}, {
+			name:             "syntax error",
+			givenGrammar:     "a <- 'a'\nb <- ('b'\n",
+			expectedMessages: []string{"line 2, column 1", "Expecting end of input"},
+		}, {
+			name:         "undefined",
+			givenGrammar: "a <- b {act}\na <- 'a'\nc <- [z-a]",
+			expectedMessages: []string{
+				"line 1, column 6:\na <- b {act}\nRule 'b' isn't defined.",
+				"line 1, column 9:\na <- b {act}\nSemantic action 'act' isn't defined.",
+				"line 2, column 1:\na <- 'a'\nRule 'a' is defined multiple times.",
+				"line 3, column 6:\nc <- [z-a]\nInvalid character class [z-a]:",
+			},
+		}, {
+			name:         "indirect left recursion",
+			givenGrammar: "expr <- sum / num\nsum <- expr '+' num\nnum <- [0-9]+",
+			expectedMessages: []string{
+				"line 1, column 1:\nexpr <- sum / num\nRule 'expr' is indirectly left recursive (expr -> sum -> expr) " +
+					"but only direct left recursion is supported.",
+				"line 2, column 1:\nsum <- expr '+' num\nRule 'sum' is indirectly left recursive (sum -> expr -> sum) " +
+					"but only direct left recursion is supported.",
+			},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.name, func(tt *testing.T) {
+			g, _, err := LoadGrammar(spec.name, spec.givenGrammar, nil)
+			if g != nil {
+				tt.Errorf("didn't expect a grammar")
+			}
+			if err == nil {
+				tt.Fatalf("expected an error")
+			}
+			for _, msg := range spec.expectedMessages {
+				if !strings.Contains(err.Error(), msg) {
+					tt.Errorf("expected error containing %q, got: %q", msg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestPegBuilder_LeftRecursion(t *testing.T) {
+	pd, rules := parsePEG("leftrec", `a <- b 'x' / 'y'
+b <- c? a
+c <- 'c'
+d <- !d 'd' / d+
+e <- 'e' e
+f <- f 'f' / g 'x' / 'f'
+g <- f 'g'
+`)
+	b := &pegBuilder{pd: pd, g: NewGrammar(), ignoreActions: true}
+	b.buildRules(rules)
+
+	expectedLeftRec := map[string]bool{"d": true, "f": true}
+	for _, r := range rules {
+		if b.leftRec[r.name] != expectedLeftRec[r.name] {
+			t.Errorf("expected direct left recursion of rule '%s' to be %t", r.name, expectedLeftRec[r.name])
+		}
+	}
+	_, err := pd.GetFeedback()
+	if err == nil {
+		t.Fatalf("expected errors for indirect left recursion")
+	}
+	for _, cycle := range []string{"a -> b -> a", "b -> a -> b", "f -> g -> f", "g -> f -> g"} {
+		if !strings.Contains(err.Error(), "indirectly left recursive ("+cycle+")") {
+			t.Errorf("expected an error for the cycle %s, got: %v", cycle, err)
+		}
+	}
+	if n := strings.Count(err.Error(), "indirectly left recursive"); n != 4 {
+		t.Errorf("expected 4 errors for indirect left recursion, got %d: %v", n, err)
+	}
+}
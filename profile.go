@@ -0,0 +1,274 @@
+package gparselib
+
+import (
+	"cmp"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RuleProfile contains the profiling data of a single grammar rule.
+type RuleProfile struct {
+	Rule      string
+	Calls     int           // number of invocations
+	Successes int           // number of matches
+	Failures  int           // number of failures
+	Bytes     int           // number of bytes consumed by all matches
+	Time      time.Duration // time spent in the rule including all rules called by it
+	SelfTime  time.Duration // time spent in the rule excluding all rules called by it
+	// Repeats is the number of invocations at a position the rule has been
+	// invoked at before.
+	// Many repeats signal (exponential) backtracking that can be fixed with
+	// memoization (see ParseMemo) or by changing the grammar.
+	Repeats int
+}
+
+// Profiler is a Tracer that collects profiling data for all grammar rules
+// (see ParseRule).
+// It has to be set in ParseData.Tracer.
+// The positions for counting repeats are kept until they are released by a
+// cut (see ParseCut) or by streaming (see ParseStream).
+// So without them memory grows with the size of the input.
+type Profiler struct {
+	rules   map[string]*RuleProfile
+	seen    map[memoKey]bool // rules and positions for counting repeats
+	stack   []profileFrame
+	samples map[string]*profileSample // key: rules of the stack
+	now     func() time.Time
+}
+
+type profileFrame struct {
+	rule     string
+	start    time.Time
+	children time.Duration // time spent in called rules
+}
+
+type profileSample struct {
+	stack []string // the rules of the call stack with the leaf first
+	calls int64
+	time  time.Duration // self time
+}
+
+// NewProfiler creates a new, empty Profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		rules:   make(map[string]*RuleProfile, 64),
+		seen:    make(map[memoKey]bool, 1024),
+		samples: make(map[string]*profileSample, 64),
+		now:     time.Now,
+	}
+}
+
+// Enter starts measuring a rule.
+// Other parsers are ignored.
+func (p *Profiler) Enter(ev TraceEvent) {
+	if ev.Parser != "rule" {
+		return
+	}
+	p.stack = append(p.stack, profileFrame{rule: ev.Rule, start: p.now()})
+}
+
+// Exit records the measurement of a rule.
+// Other parsers are ignored.
+func (p *Profiler) Exit(ev TraceEvent) {
+	if ev.Parser != "rule" || len(p.stack) == 0 {
+		return
+	}
+	last := len(p.stack) - 1
+	frame := p.stack[last]
+	p.stack = p.stack[:last]
+	elapsed := p.now().Sub(frame.start)
+	if last > 0 {
+		p.stack[last-1].children += elapsed
+	}
+
+	rp := p.rules[ev.Rule]
+	if rp == nil {
+		rp = &RuleProfile{Rule: ev.Rule}
+		p.rules[ev.Rule] = rp
+	}
+	rp.Calls++
+	if ev.Success {
+		rp.Successes++
+		rp.Bytes += len(ev.Text)
+	} else {
+		rp.Failures++
+	}
+	key := memoKey{ev.Rule, ev.Pos}
+	if p.seen[key] {
+		rp.Repeats++
+	}
+	p.seen[key] = true
+	if !slices.ContainsFunc(p.stack, func(f profileFrame) bool { return f.rule == ev.Rule }) {
+		rp.Time += elapsed // recursive calls are part of the outermost call
+	}
+	rp.SelfTime += elapsed - frame.children
+
+	stack := make([]string, 0, len(p.stack)+1)
+	stack = append(stack, ev.Rule)
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		stack = append(stack, p.stack[i].rule)
+	}
+	skey := strings.Join(stack, "\x00")
+	s := p.samples[skey]
+	if s == nil {
+		s = &profileSample{stack: stack}
+		p.samples[skey] = s
+	}
+	s.calls++
+	s.time += elapsed - frame.children
+}
+
+// releaseBefore forgets the positions before pos because no parser will
+// backtrack before it.
+func (p *Profiler) releaseBefore(pos int) {
+	for key := range p.seen {
+		if key.pos < pos {
+			delete(p.seen, key)
+		}
+	}
+}
+
+// Profiles returns the profiling data of all rules sorted by time (the
+// slowest rule first).
+func (p *Profiler) Profiles() []RuleProfile {
+	profiles := make([]RuleProfile, 0, len(p.rules))
+	for _, rp := range p.rules {
+		profiles = append(profiles, *rp)
+	}
+	slices.SortFunc(profiles, func(a, b RuleProfile) int {
+		if n := cmp.Compare(b.Time, a.Time); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Rule, b.Rule)
+	})
+	return profiles
+}
+
+// WriteReport writes the profiling data of all rules as a text table sorted
+// by time.
+func (p *Profiler) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "rule\tcalls\tsuccesses\tfailures\tbytes\trepeats\ttime\tself time\t")
+	for _, rp := range p.Profiles() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%v\t%v\t\n",
+			rp.Rule, rp.Calls, rp.Successes, rp.Failures, rp.Bytes, rp.Repeats, rp.Time, rp.SelfTime)
+	}
+	return tw.Flush()
+}
+
+// WritePprof writes the profiling data in the (gzipped) format of pprof
+// (see github.com/google/pprof).
+// Every rule is a function and the call stacks are the stacks of rules.
+// The samples contain the number of calls and the self time.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	strs := []string{""}
+	strIndex := map[string]int64{"": 0}
+	str := func(s string) int64 {
+		i, ok := strIndex[s]
+		if !ok {
+			i = int64(len(strs))
+			strs = append(strs, s)
+			strIndex[s] = i
+		}
+		return i
+	}
+	valueType := func(typ, unit string) []byte {
+		var b protoBuffer
+		b.int64(1, str(typ))
+		b.int64(2, str(unit))
+		return b
+	}
+
+	var prof protoBuffer
+	prof.bytes(1, valueType("calls", "count"))
+	prof.bytes(1, valueType("time", "nanoseconds"))
+
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	ids := map[string]uint64{} // rule -> function and location ID
+	var rules []string
+	for _, key := range keys {
+		s := p.samples[key]
+		locs := make([]uint64, len(s.stack))
+		for i, rule := range s.stack {
+			id, ok := ids[rule]
+			if !ok {
+				id = uint64(len(ids) + 1)
+				ids[rule] = id
+				rules = append(rules, rule)
+			}
+			locs[i] = id
+		}
+		var sample protoBuffer
+		sample.uint64s(1, locs)
+		sample.int64s(2, []int64{s.calls, int64(s.time)})
+		prof.bytes(2, sample)
+	}
+	for i, rule := range rules {
+		id := uint64(i + 1)
+		var line, loc, fn protoBuffer
+		line.uint64(1, id)
+		loc.uint64(1, id)
+		loc.bytes(4, line)
+		prof.bytes(4, loc)
+		fn.uint64(1, id)
+		fn.int64(2, str(rule))
+		fn.int64(3, str(rule))
+		prof.bytes(5, fn)
+	}
+	periodType := valueType("time", "nanoseconds")
+	for _, s := range strs {
+		prof.bytes(6, []byte(s))
+	}
+	prof.bytes(11, periodType)
+	prof.int64(12, 1)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(prof); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protoBuffer encodes protocol buffer messages.
+// Only the few wire types needed for pprof are supported.
+type protoBuffer []byte
+
+func (b *protoBuffer) tag(field, wireType int) {
+	*b = binary.AppendUvarint(*b, uint64(field<<3|wireType))
+}
+func (b *protoBuffer) uint64(field int, v uint64) {
+	b.tag(field, 0)
+	*b = binary.AppendUvarint(*b, v)
+}
+func (b *protoBuffer) int64(field int, v int64) {
+	b.uint64(field, uint64(v))
+}
+func (b *protoBuffer) bytes(field int, v []byte) {
+	b.tag(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+func (b *protoBuffer) uint64s(field int, vs []uint64) {
+	var packed []byte
+	for _, v := range vs {
+		packed = binary.AppendUvarint(packed, v)
+	}
+	b.bytes(field, packed)
+}
+func (b *protoBuffer) int64s(field int, vs []int64) {
+	var packed []byte
+	for _, v := range vs {
+		packed = binary.AppendUvarint(packed, uint64(v))
+	}
+	b.bytes(field, packed)
+}
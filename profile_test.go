@@ -0,0 +1,188 @@
+package gparselib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProfiler() *Profiler {
+	p := NewProfiler()
+	now := time.Time{}
+	p.now = func() time.Time { // every call takes a millisecond
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	return p
+}
+
+func TestProfiler(t *testing.T) {
+	calc := loadCalcGrammar(t).Start()
+	p := newTestProfiler()
+	pd := NewParseData("test", "1 + (2 - 3)")
+	pd.Tracer = p
+	pd, _ = calc(pd, nil)
+	if pd.Result.HasError() {
+		t.Fatalf("unexpected errors: %s", printErrors(pd.Result.Feedback))
+	}
+
+	profiles := p.Profiles()
+	if len(profiles) != 5 || profiles[0].Rule != "calc" {
+		t.Fatalf("expected 5 rules with 'calc' first, got: %+v", profiles)
+	}
+	byRule := map[string]RuleProfile{}
+	for _, rp := range profiles {
+		byRule[rp.Rule] = rp
+		if rp.Calls != rp.Successes+rp.Failures {
+			t.Errorf("expected calls to be successes plus failures, got: %+v", rp)
+		}
+		if rp.SelfTime > rp.Time || rp.SelfTime <= 0 {
+			t.Errorf("expected a positive self time of at most the time, got: %+v", rp)
+		}
+	}
+	// every call of a rule calls the clock twice
+	if rp := byRule["calc"]; rp.Calls != 1 || rp.Bytes != 11 || rp.Time != time.Duration(2*countRuleCalls(byRule)-1)*time.Millisecond {
+		t.Errorf("unexpected profile of the start rule: %+v", rp)
+	}
+	// without a memo table numbers are parsed again in every round of the left recursion
+	if rp := byRule["number"]; rp.Successes != 5 || rp.Bytes != 9 {
+		t.Errorf("expected 5 numbers with 9 bytes, got: %+v", rp)
+	}
+	if rp := byRule["expr"]; rp.Repeats == 0 {
+		t.Errorf("expected the left recursive rule to be repeated, got: %+v", rp)
+	}
+
+	b := &strings.Builder{}
+	if err := p.WriteReport(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 6 || !strings.Contains(lines[0], "repeats") || !strings.Contains(lines[1], "calc") {
+		t.Errorf("unexpected report:\n%s", b.String())
+	}
+}
+
+func TestProfiler_Release(t *testing.T) {
+	g := NewGrammar()
+	g.Define("list", NewParseStreamPlugin(g.Ref("item"), nil))
+	g.Define("item", NewParseAllPlugin([]SubparserOp{g.Ref("a"), NewParseCutPlugin(), g.Ref("a")}, nil))
+	g.Define("a", NewParseLiteralPlugin(nil, "a\n"))
+
+	p := newTestProfiler()
+	pd := NewStreamParseData("stream", strings.NewReader(strings.Repeat("a\n", 100)), 16)
+	pd.Tracer = p
+	pd, _ = g.Start()(pd, nil)
+	if pd.Result.HasError() {
+		t.Fatalf("unexpected errors: %s", printErrors(pd.Result.Feedback))
+	}
+	if len(p.seen) > 4 {
+		t.Errorf("expected the positions before the released content to be forgotten, got %d", len(p.seen))
+	}
+
+	p = newTestProfiler()
+	pd = NewParseData("cut", "a\na\n")
+	pd.Tracer = p
+	pd, _ = g.Ref("item")(pd, nil)
+	if pd.Result.HasError() {
+		t.Fatalf("unexpected errors: %s", printErrors(pd.Result.Feedback))
+	}
+	if p.seen[memoKey{"a", 0}] || !p.seen[memoKey{"a", 2}] {
+		t.Errorf("expected only the positions after the cut to be kept, got: %v", p.seen)
+	}
+}
+
+func countRuleCalls(byRule map[string]RuleProfile) int {
+	n := 0
+	for _, rp := range byRule {
+		n += rp.Calls
+	}
+	return n
+}
+
+func TestProfiler_WritePprof(t *testing.T) {
+	p := newTestProfiler()
+	p.Enter(TraceEvent{Parser: "rule", Rule: "outer"})
+	p.Enter(TraceEvent{Parser: "literal 'x'", Rule: "outer"})
+	p.Enter(TraceEvent{Parser: "rule", Rule: "inner"})
+	p.Exit(TraceEvent{Parser: "rule", Rule: "inner", Success: true, Text: "x"})
+	p.Exit(TraceEvent{Parser: "literal 'x'", Rule: "outer"})
+	p.Exit(TraceEvent{Parser: "rule", Rule: "outer", Success: true, Text: "x"})
+
+	buf := &bytes.Buffer{}
+	if err := p.WritePprof(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zr, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var strs []string
+	var samples [][]byte
+	for field, v := range protoFields(t, data) {
+		switch field {
+		case 2:
+			samples = append(samples, v)
+		case 6:
+			strs = append(strs, string(v))
+		}
+	}
+	for _, s := range []string{"", "calls", "count", "time", "nanoseconds", "outer", "inner"} {
+		if !slices.Contains(strs, s) {
+			t.Errorf("expected string %q in the string table, got: %q", s, strs)
+		}
+	}
+	if strs[0] != "" {
+		t.Errorf("expected an empty first string, got: %q", strs[0])
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got: %d", len(samples))
+	}
+	var values []uint64
+	for field, v := range protoFields(t, samples[0]) {
+		if field == 2 {
+			for len(v) > 0 {
+				x, n := binary.Uvarint(v)
+				values = append(values, x)
+				v = v[n:]
+			}
+		}
+	}
+	if len(values) != 2 || values[0] != 1 || values[1] != uint64(time.Millisecond) {
+		t.Errorf("expected 1 call and 1ms self time for the inner rule, got: %v", values)
+	}
+}
+
+// protoFields iterates over the length delimited fields of a protocol buffer
+// message (other fields are skipped).
+func protoFields(t *testing.T, data []byte) func(yield func(int, []byte) bool) {
+	return func(yield func(int, []byte) bool) {
+		for len(data) > 0 {
+			tag, n := binary.Uvarint(data)
+			data = data[n:]
+			switch tag & 7 {
+			case 0:
+				_, n = binary.Uvarint(data)
+				data = data[n:]
+			case 2:
+				l, n := binary.Uvarint(data)
+				v := data[n : n+int(l)]
+				data = data[n+int(l):]
+				if !yield(int(tag>>3), v) {
+					return
+				}
+			default:
+				t.Fatalf("unexpected wire type %d", tag&7)
+			}
+		}
+	}
+}
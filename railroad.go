@@ -0,0 +1,431 @@
+package gparselib
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Dimensions of railroad diagrams in pixels.
+const (
+	rrArc       = 10 // radius of all arcs
+	rrGap       = 10 // horizontal space between elements
+	rrVGap      = 8  // vertical space between alternatives
+	rrBoxHeight = 22
+	rrCharWidth = 8 // of the monospace font
+	rrTextLine  = 14
+	rrPadding   = 20
+)
+
+const railroadStyle = `svg.railroad { background-color: white; }
+svg.railroad path { stroke-width: 2; stroke: black; fill: none; }
+svg.railroad rect { stroke-width: 2; stroke: black; fill: #eef6ff; }
+svg.railroad .terminal rect { fill: #f2fff0; }
+svg.railroad .special rect { fill: #fffbe6; }
+svg.railroad .group rect { stroke: gray; stroke-dasharray: 4 3; fill: none; }
+svg.railroad text { font: 13px monospace; text-anchor: middle; }
+svg.railroad .special text, svg.railroad .comment { font-style: italic; }
+svg.railroad .group text { text-anchor: start; fill: gray; }
+svg.railroad a text { fill: #0645ad; }
+`
+
+// WriteRailroadSVG writes a self-contained SVG railroad diagram for the
+// parser described by the descriptor.
+// References to rules aren't links.
+func WriteRailroadSVG(w io.Writer, d *Descriptor) error {
+	b := &strings.Builder{}
+	writeRailroad(b, d, nil, true)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteRailroadSVG writes a self-contained SVG railroad diagram for the rule
+// with the given name.
+// If the rule isn't defined an error is returned.
+func (g *Grammar) WriteRailroadSVG(w io.Writer, rule string) error {
+	for _, d := range g.Describe() {
+		if d.Name == rule && len(d.Children) > 0 {
+			return WriteRailroadSVG(w, d.Children[0])
+		}
+	}
+	return fmt.Errorf("rule '%s' isn't defined", rule)
+}
+
+// WriteRailroadHTML writes a self-contained HTML page with a railroad diagram
+// for every defined rule of the grammar.
+// The diagrams are in order of first appearance of the rules and references
+// to rules are links to their diagrams.
+func (g *Grammar) WriteRailroadHTML(w io.Writer, title string) error {
+	link := func(rule string) string {
+		if r := g.rules[rule]; r == nil || r.subparser == nil {
+			return ""
+		}
+		return "#" + railroadID(rule)
+	}
+	b := &strings.Builder{}
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(b, "<title>%s</title>\n", html.EscapeString(title))
+	b.WriteString("<style>\nbody { font-family: sans-serif; }\n" + railroadStyle + "</style>\n")
+	fmt.Fprintf(b, "</head>\n<body>\n<h1>%s</h1>\n", html.EscapeString(title))
+	for _, d := range g.Describe() {
+		if len(d.Children) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "<h2 id=\"%s\">%s</h2>\n", railroadID(d.Name), html.EscapeString(d.Name))
+		writeRailroad(b, d.Children[0], link, false)
+	}
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func railroadID(rule string) string {
+	return "rule-" + strings.ReplaceAll(rule, " ", "_")
+}
+
+// writeRailroad writes the SVG diagram.
+// The link function returns the target of references to rules (or an empty
+// string for no link).
+func writeRailroad(b *strings.Builder, d *Descriptor, link func(string) string, withStyle bool) {
+	n := railroadNode(d, link)
+	w, up, down := n.size()
+	width := w + 2*rrPadding + 2*rrGap
+	height := up + down + 2*rrPadding
+	y := rrPadding + up
+	fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" class=\"railroad\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	if withStyle {
+		b.WriteString("<style>\n" + railroadStyle + "</style>\n")
+	}
+	fmt.Fprintf(b, "<path d=\"M%d %d v%d M%d %d h%d\"/>\n", rrPadding, y-rrBoxHeight/2, rrBoxHeight, rrPadding, y, rrGap)
+	n.draw(b, rrPadding+rrGap, y)
+	x := rrPadding + rrGap + w
+	fmt.Fprintf(b, "<path d=\"M%d %d h%d m0 %d v%d\"/>\n", x, y, rrGap, -rrBoxHeight/2, rrBoxHeight)
+	b.WriteString("</svg>\n")
+}
+
+// railroadNode converts the descriptor into nodes of a railroad diagram.
+func railroadNode(d *Descriptor, link func(string) string) rrNode {
+	child := func(i int) rrNode {
+		if i >= len(d.Children) {
+			return newRRBox("special", "custom", "")
+		}
+		return railroadNode(d.Children[i], link)
+	}
+	children := func() []rrNode {
+		nodes := make([]rrNode, len(d.Children))
+		for i := range d.Children {
+			nodes[i] = child(i)
+		}
+		return nodes
+	}
+	config := func(i int) string {
+		if i >= len(d.Config) {
+			return ""
+		}
+		return d.Config[i]
+	}
+
+	switch d.Kind {
+	case KindLiteral:
+		return newRRBox("terminal", quotePEG(config(0)), "")
+	case KindRegexp:
+		return newRRBox("terminal", regexpPEG(config(0)), "")
+	case KindIdent:
+		return newRRBox("special", "identifier", "")
+	case KindNatural:
+		return newRRBox("special", "natural number", "")
+	case KindEOF:
+		return newRRBox("special", "end of input", "")
+	case KindSpace:
+		return newRRBox("special", "white space", "")
+	case KindLineComment:
+		return newRRBox("special", "comment "+quotePEG(config(0)), "")
+	case KindBlockComment:
+		return newRRBox("special", "comment "+quotePEG(config(0))+" … "+quotePEG(config(1)), "")
+	case KindGoodRunes:
+		return newRRBox("special", "good runes", "")
+	case KindMulti:
+		return newRRMulti(child(0), d.Min, d.Max)
+	case KindOptional:
+		return newRRChoice(newRRSeq(nil), child(0))
+	case KindNot:
+		return newRRGroup("not", child(0))
+	case KindAnd:
+		return newRRGroup("followed by", child(0))
+	case KindAll:
+		return newRRSeq(children())
+	case KindAny:
+		return newRRChoice(children()...)
+	case KindBest:
+		return newRRGroup("longest of", newRRChoice(children()...))
+	case KindCut:
+		return newRRComment("cut")
+	case KindLabel:
+		return newRRBox("nonterminal", d.Name, "")
+	case KindStream:
+		return newRRChoice(newRRSeq(nil), newRRLoop(child(0), ""))
+	case KindRecover, KindMemo, KindLeftRec, KindRule:
+		return child(0)
+	case KindRef:
+		href := ""
+		if link != nil {
+			href = link(d.Name)
+		}
+		return newRRBox("nonterminal", d.Name, href)
+	}
+	if config(0) == descRecursion {
+		return newRRBox("special", descRecursion, "")
+	}
+	if len(d.Children) > 0 {
+		return newRRSeq(children())
+	}
+	return newRRBox("special", "custom", "")
+}
+
+func newRRMulti(n rrNode, cfgMin, cfgMax int) rrNode {
+	switch {
+	case cfgMax <= 0:
+		return newRRSeq(nil)
+	case cfgMin == 1 && cfgMax == 1:
+		return n
+	case cfgMin == 0 && cfgMax == 1:
+		return newRRChoice(newRRSeq(nil), n)
+	}
+	label := ""
+	switch {
+	case cfgMax == math.MaxInt32 && cfgMin > 1:
+		label = "at least " + strconv.Itoa(cfgMin) + " times"
+	case cfgMax != math.MaxInt32 && cfgMin == cfgMax:
+		label = strconv.Itoa(cfgMin) + " times"
+	case cfgMax != math.MaxInt32:
+		label = strconv.Itoa(max(cfgMin, 1)) + " to " + strconv.Itoa(cfgMax) + " times"
+	}
+	loop := newRRLoop(n, label)
+	if cfgMin <= 0 {
+		return newRRChoice(newRRSeq(nil), loop)
+	}
+	return loop
+}
+
+// rrNode is a node of a railroad diagram.
+type rrNode interface {
+	// size returns the width and the extent above and below the line the
+	// node is connected to.
+	size() (w, up, down int)
+	// draw draws the node starting at x with the connecting line at y.
+	draw(b *strings.Builder, x, y int)
+}
+
+func textWidth(text string) int {
+	return utf8.RuneCountInString(text) * rrCharWidth
+}
+
+func drawLine(b *strings.Builder, x, y, w int) {
+	if w > 0 {
+		fmt.Fprintf(b, "<path d=\"M%d %d h%d\"/>\n", x, y, w)
+	}
+}
+
+// rrBox is a terminal, nonterminal or special box.
+type rrBox struct {
+	class, text, href string
+	w                 int
+}
+
+func newRRBox(class, text, href string) *rrBox {
+	return &rrBox{class: class, text: text, href: href, w: textWidth(text) + 2*rrGap}
+}
+func (n *rrBox) size() (int, int, int) {
+	return n.w, rrBoxHeight / 2, rrBoxHeight / 2
+}
+func (n *rrBox) draw(b *strings.Builder, x, y int) {
+	fmt.Fprintf(b, "<g class=\"%s\">", n.class)
+	if n.href != "" {
+		fmt.Fprintf(b, "<a href=\"%s\">", html.EscapeString(n.href))
+	}
+	rx := rrBoxHeight / 2
+	if n.class == "nonterminal" {
+		rx = 0
+	}
+	fmt.Fprintf(b, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"%d\"/>",
+		x, y-rrBoxHeight/2, n.w, rrBoxHeight, rx)
+	fmt.Fprintf(b, "<text x=\"%d\" y=\"%d\">%s</text>", x+n.w/2, y+4, html.EscapeString(n.text))
+	if n.href != "" {
+		b.WriteString("</a>")
+	}
+	b.WriteString("</g>\n")
+}
+
+// rrSeq is a sequence of nodes.
+// An empty sequence is just a connection.
+type rrSeq struct {
+	nodes       []rrNode
+	w, up, down int
+}
+
+func newRRSeq(nodes []rrNode) *rrSeq {
+	n := &rrSeq{nodes: nodes}
+	for i, node := range nodes {
+		w, up, down := node.size()
+		if i > 0 {
+			n.w += rrGap
+		}
+		n.w += w
+		n.up = max(n.up, up)
+		n.down = max(n.down, down)
+	}
+	return n
+}
+func (n *rrSeq) size() (int, int, int) {
+	return n.w, n.up, n.down
+}
+func (n *rrSeq) draw(b *strings.Builder, x, y int) {
+	for i, node := range n.nodes {
+		if i > 0 {
+			drawLine(b, x, y, rrGap)
+			x += rrGap
+		}
+		node.draw(b, x, y)
+		w, _, _ := node.size()
+		x += w
+	}
+}
+
+// rrChoice contains alternatives below each other with the first one on the
+// connecting line.
+type rrChoice struct {
+	nodes       []rrNode
+	ys          []int // vertical offsets of the alternatives
+	w, up, down int
+}
+
+func newRRChoice(nodes ...rrNode) *rrChoice {
+	n := &rrChoice{nodes: nodes, ys: make([]int, len(nodes))}
+	prevDown := 0
+	for i, node := range nodes {
+		w, up, down := node.size()
+		n.w = max(n.w, w)
+		if i == 0 {
+			n.up = up
+		} else {
+			n.ys[i] = max(n.ys[i-1]+prevDown+rrVGap+up, n.ys[i-1]+2*rrArc)
+		}
+		prevDown = down
+		n.down = n.ys[i] + down
+	}
+	n.w += 4 * rrArc
+	return n
+}
+func (n *rrChoice) size() (int, int, int) {
+	return n.w, n.up, n.down
+}
+func (n *rrChoice) draw(b *strings.Builder, x, y int) {
+	for i, node := range n.nodes {
+		w, _, _ := node.size()
+		yi := y + n.ys[i]
+		if i == 0 {
+			drawLine(b, x, y, 2*rrArc)
+		} else {
+			fmt.Fprintf(b, "<path d=\"M%d %d a%d %d 0 0 1 %d %d v%d a%d %d 0 0 0 %d %d\"/>\n",
+				x, y, rrArc, rrArc, rrArc, rrArc, n.ys[i]-2*rrArc, rrArc, rrArc, rrArc, rrArc)
+		}
+		node.draw(b, x+2*rrArc, yi)
+		if i == 0 {
+			drawLine(b, x+2*rrArc+w, y, n.w-2*rrArc-w)
+			continue
+		}
+		drawLine(b, x+2*rrArc+w, yi, n.w-4*rrArc-w)
+		fmt.Fprintf(b, "<path d=\"M%d %d a%d %d 0 0 0 %d %d v%d a%d %d 0 0 1 %d %d\"/>\n",
+			x+n.w-2*rrArc, yi, rrArc, rrArc, rrArc, -rrArc, -(n.ys[i] - 2*rrArc), rrArc, rrArc, rrArc, -rrArc)
+	}
+}
+
+// rrLoop is a node that can be repeated by going back below it.
+type rrLoop struct {
+	node        rrNode
+	label       string
+	ret         int // vertical offset of the way back
+	w, up, down int
+}
+
+func newRRLoop(node rrNode, label string) *rrLoop {
+	w, up, down := node.size()
+	n := &rrLoop{node: node, label: label, w: w + 2*rrArc, up: up}
+	n.ret = max(down+rrVGap, 2*rrArc)
+	n.down = n.ret
+	if label != "" {
+		n.down += rrTextLine
+		n.w = max(n.w, textWidth(label)+2*rrArc)
+	}
+	return n
+}
+func (n *rrLoop) size() (int, int, int) {
+	return n.w, n.up, n.down
+}
+func (n *rrLoop) draw(b *strings.Builder, x, y int) {
+	w, _, _ := n.node.size()
+	x0 := x + (n.w-w-2*rrArc)/2 // centered if the label is wider
+	drawLine(b, x, y, x0-x+rrArc)
+	n.node.draw(b, x0+rrArc, y)
+	drawLine(b, x0+rrArc+w, y, x+n.w-x0-rrArc-w)
+	fmt.Fprintf(b, "<path d=\"M%d %d a%d %d 0 0 1 %d %d v%d a%d %d 0 0 1 %d %d h%d a%d %d 0 0 1 %d %d v%d a%d %d 0 0 1 %d %d\"/>\n",
+		x0+rrArc+w, y, rrArc, rrArc, rrArc, rrArc, n.ret-2*rrArc, rrArc, rrArc, -rrArc, rrArc,
+		-w, rrArc, rrArc, -rrArc, -rrArc, -(n.ret - 2*rrArc), rrArc, rrArc, rrArc, -rrArc)
+	if n.label != "" {
+		fmt.Fprintf(b, "<text class=\"comment\" x=\"%d\" y=\"%d\">%s</text>\n",
+			x+n.w/2, y+n.ret+rrTextLine-2, html.EscapeString(n.label))
+	}
+}
+
+// rrGroup is a labeled, dashed box around a node.
+type rrGroup struct {
+	node        rrNode
+	label       string
+	w, up, down int
+}
+
+func newRRGroup(label string, node rrNode) *rrGroup {
+	w, up, down := node.size()
+	return &rrGroup{
+		node:  node,
+		label: label,
+		w:     max(w, textWidth(label)) + 2*rrGap,
+		up:    up + rrGap + rrTextLine,
+		down:  down + rrGap,
+	}
+}
+func (n *rrGroup) size() (int, int, int) {
+	return n.w, n.up, n.down
+}
+func (n *rrGroup) draw(b *strings.Builder, x, y int) {
+	w, _, _ := n.node.size()
+	top := y - n.up + rrTextLine
+	fmt.Fprintf(b, "<g class=\"group\"><rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"%d\"/>",
+		x, top, n.w, n.up+n.down-rrTextLine, rrArc/2)
+	fmt.Fprintf(b, "<text x=\"%d\" y=\"%d\">%s</text></g>\n", x+2, top-4, html.EscapeString(n.label))
+	drawLine(b, x, y, rrGap)
+	n.node.draw(b, x+rrGap, y)
+	drawLine(b, x+rrGap+w, y, n.w-rrGap-w)
+}
+
+// rrComment is a text on the connecting line.
+type rrComment struct {
+	text string
+	w    int
+}
+
+func newRRComment(text string) *rrComment {
+	return &rrComment{text: text, w: textWidth(text) + 2*rrGap}
+}
+func (n *rrComment) size() (int, int, int) {
+	return n.w, rrTextLine, 0
+}
+func (n *rrComment) draw(b *strings.Builder, x, y int) {
+	drawLine(b, x, y, n.w)
+	fmt.Fprintf(b, "<text class=\"comment\" x=\"%d\" y=\"%d\">%s</text>\n", x+n.w/2, y-4, html.EscapeString(n.text))
+}
@@ -0,0 +1,151 @@
+package gparselib
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteRailroadSVG(t *testing.T) {
+	pA := NewParseLiteralPlugin(nil, "a<b")
+	pMulti := NewParseAllPlugin([]SubparserOp{
+		NewParseMulti0Plugin(pA, nil),
+		NewParseMultiPlugin(pA, nil, 2, 3),
+	}, nil)
+
+	specs := []struct {
+		name           string
+		givenOp        SubparserOp
+		expectedTexts  []string
+		expectedRects  int
+		expectedRounds int // rounded rectangles
+	}{
+		{
+			name:           "literal",
+			givenOp:        pA,
+			expectedTexts:  []string{"'a<b'"},
+			expectedRects:  1,
+			expectedRounds: 1,
+		}, {
+			name:           "multi",
+			givenOp:        pMulti,
+			expectedTexts:  []string{"'a<b'", "'a<b'", "2 to 3 times"},
+			expectedRects:  2,
+			expectedRounds: 2,
+		}, {
+			name: "choice",
+			givenOp: NewParseAnyPlugin([]SubparserOp{
+				NewParseLabelPlugin("letter", pA),
+				NewParseNotPlugin(NewParseEOFPlugin(nil), nil),
+				ParseCut,
+			}, nil),
+			expectedTexts:  []string{"letter", "not", "end of input", "cut"},
+			expectedRects:  2,
+			expectedRounds: 1,
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			b := &strings.Builder{}
+			if err := WriteRailroadSVG(b, Describe(spec.givenOp)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			texts, rects, rounds := parseSVG(t, b.String())
+			if strings.Join(texts, "|") != strings.Join(spec.expectedTexts, "|") {
+				t.Errorf("expected texts %q, got: %q", spec.expectedTexts, texts)
+			}
+			if rects != spec.expectedRects {
+				t.Errorf("expected %d rectangles, got: %d", spec.expectedRects, rects)
+			}
+			if rounds != spec.expectedRounds {
+				t.Errorf("expected %d rounded rectangles, got: %d", spec.expectedRounds, rounds)
+			}
+		})
+	}
+}
+
+func TestGrammar_WriteRailroad(t *testing.T) {
+	g := loadCalcGrammar(t)
+
+	b := &strings.Builder{}
+	if err := g.WriteRailroadSVG(b, "term"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	texts, _, _ := parseSVG(t, b.String())
+	expected := []string{"number", "'('", "space", "expr", "')'", "space"}
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected texts %q, got: %q", expected, texts)
+	}
+	if err := g.WriteRailroadSVG(b, "missing"); err == nil {
+		t.Errorf("expected error for missing rule")
+	}
+
+	b.Reset()
+	if err := g.WriteRailroadHTML(b, "Calc & Co."); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page := b.String()
+	for _, s := range []string{
+		"<title>Calc &amp; Co.</title>",
+		`<h2 id="rule-calc">calc</h2>`,
+		`<h2 id="rule-keyword">keyword</h2>`,
+		`<a href="#rule-expr"><rect`,
+		`<a href="#rule-space"><rect`,
+	} {
+		if !strings.Contains(page, s) {
+			t.Errorf("expected HTML to contain %q", s)
+		}
+	}
+	if n := strings.Count(page, "<svg "); n != 7 {
+		t.Errorf("expected 7 diagrams, got: %d", n)
+	}
+	if n := strings.Count(page, "<style>"); n != 1 {
+		t.Errorf("expected only one style sheet, got: %d", n)
+	}
+}
+
+// parseSVG checks that the SVG is well-formed and returns its texts and the
+// number of rectangles that aren't groups.
+func parseSVG(t *testing.T, svg string) (texts []string, rects, rounds int) {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	inText, inGroup := false, false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return texts, rects, rounds
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v\n%s", err, svg)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "text":
+				inText = true
+			case "g":
+				for _, attr := range tok.Attr {
+					inGroup = inGroup || attr.Name.Local == "class" && attr.Value == "group"
+				}
+			case "rect":
+				if inGroup { // the frame of a group
+					inGroup = false
+					continue
+				}
+				rects++
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "rx" && attr.Value != "0" {
+						rounds++
+					}
+				}
+			}
+		case xml.CharData:
+			if inText {
+				texts = append(texts, string(tok))
+			}
+		case xml.EndElement:
+			inText = false
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package gparselib
+
+import "unicode/utf8"
+
+// ErrorNode is the value of input that has been skipped by ParseRecover.
+type ErrorNode struct {
+	Pos  int    // position of the skipped input
+	Text string // the skipped input
+}
+
+// ParseRecover uses its subparser exactly one time.
+// If the subparser doesn't match, its errors are kept as recovered errors
+// (FeedbackRecoveredError) and the input is skipped until the
+// synchronization parser matches (e.g.: `;`, a newline or `}`).
+// The synchronization parser is consumed, too; it can be wrapped by ParseAnd
+// to keep the synchronization point (e.g. a closing `}`) for the parent parser.
+// The recovered result matches the skipped input and has an *ErrorNode as
+// value. So parent parsers (e.g. ParseMulti or ParseAll) keep parsing and a
+// single run finds all syntax errors.
+// If the synchronization parser doesn't match at all, the rest of the input
+// is skipped.
+// The result is only unsuccessful if the subparser fails at the end of the
+// input because nothing can be skipped there.
+// Recovered results shouldn't be followed by alternatives (e.g. in ParseAny)
+// because they always match.
+func ParseRecover(
+	pd *ParseData, ctx interface{},
+	pluginSubparser, pluginSync SubparserOp, pluginSemantics SemanticsOp,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindRecover}, pluginSubparser, pluginSync)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "recover"))
+	}
+	orgPos := pd.Source.pos
+
+	pd.backtracks++
+	pd, ctx = pluginSubparser(pd, ctx)
+	pd.backtracks--
+	if !pd.Result.HasError() {
+		return handleSemantics(pluginSemantics, pd, ctx)
+	}
+	pd.Source.touch(orgPos + 1)
+	if pd.Source.from(orgPos, 1) == "" {
+		return pd, ctx
+	}
+
+	subresult := pd.Result
+	var end int
+	end, ctx = syncPos(pd, ctx, pluginSync, orgPos)
+	pd.Source.pos = orgPos
+	pd.Result = nil
+	createMatchedResult(pd, end-orgPos)
+	pd.Result.Value = &ErrorNode{Pos: orgPos, Text: pd.Result.Text}
+	for _, fb := range subresult.Feedback {
+		if fb.Kind == FeedbackError {
+			fb = &FeedbackItem{Pos: fb.Pos, Kind: FeedbackRecoveredError, Msg: fb.Msg}
+		}
+		pd.Result.Feedback = append(pd.Result.Feedback, fb)
+	}
+	return handleSemantics(pluginSemantics, pd, ctx)
+}
+
+// NewParseRecoverPlugin creates a plugin sporting a parser that recovers
+// from errors of its subparser.
+func NewParseRecoverPlugin(
+	pluginSubparser, pluginSync SubparserOp, pluginSemantics SemanticsOp,
+) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseRecover(pd, ctx, pluginSubparser, pluginSync, pluginSemantics)
+	}
+}
+
+// syncPos returns the position after the first match of the synchronization
+// parser that consumes input from the given position on.
+// If there is no such match, the end of the input is returned.
+// The synchronization parser is treated like a predicate, so its failures
+// don't show up in FurthestError.
+func syncPos(pd *ParseData, ctx interface{}, pluginSync SubparserOp, pos int) (int, interface{}) {
+	orgPos := pos
+	pd.predicates++
+	for {
+		pd.Source.pos = pos
+		pd.Result = nil
+		pd, ctx = pluginSync(pd, ctx)
+		if !pd.Result.HasError() && pd.Source.pos > orgPos {
+			break
+		}
+		pd.Source.touch(pos + 1)
+		rest := pd.Source.from(pos, 1)
+		if rest == "" {
+			pd.Source.pos = pos
+			break
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		pos += size
+	}
+	pd.predicates--
+	return pd.Source.pos, ctx
+}
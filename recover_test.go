@@ -0,0 +1,109 @@
+package gparselib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRecover(t *testing.T) {
+	pSemicolon := NewParseLiteralPlugin(nil, ";")
+	pStmt := NewParseAllPlugin([]SubparserOp{
+		NewParseLiteralPlugin(nil, "x"),
+		pSemicolon,
+	}, nil)
+	p := NewParseRecoverPlugin(pStmt, pSemicolon, nil)
+
+	runTests(t, p, []parseTestData{
+		{
+			givenParseData:   newData("match", 0, "x;y"),
+			expectedResult:   newResult(0, "x;", []interface{}{nil, nil}, -1),
+			expectedSrcPos:   2,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("recover", 1, " y;x;"),
+			expectedResult:   newResult(1, "y;", &ErrorNode{Pos: 1, Text: "y;"}, -1),
+			expectedSrcPos:   3,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("recover at sync", 0, ";;"),
+			expectedResult:   newResult(0, ";", &ErrorNode{Pos: 0, Text: ";"}, -1),
+			expectedSrcPos:   1,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("skip rest", 0, "yäy"),
+			expectedResult:   newResult(0, "yäy", &ErrorNode{Pos: 0, Text: "yäy"}, -1),
+			expectedSrcPos:   4,
+			expectedErrCount: 0,
+		}, {
+			givenParseData:   newData("end of input", 1, "x"),
+			expectedResult:   newResult(1, "", nil, 1),
+			expectedSrcPos:   1,
+			expectedErrCount: 1,
+		},
+	})
+
+	pd, _ := p(newData("recovered errors", 0, "y;"), nil)
+	if len(pd.Result.Feedback) == 0 {
+		t.Fatalf("expected recovered errors")
+	}
+	for _, fb := range pd.Result.Feedback {
+		if fb.Kind != FeedbackRecoveredError {
+			t.Errorf("expected only recovered errors, got: %s", fb)
+		}
+	}
+}
+
+func TestParseRecover_AllErrors(t *testing.T) {
+	pSpace := NewParseSpacePlugin(nil, true)
+	pNatural, err := NewParseNaturalPlugin(nil, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pStmt := NewParseAllPlugin([]SubparserOp{
+		NewParseIdentPlugin(nil, "", ""),
+		NewParseOptionalPlugin(pSpace, nil),
+		NewParseLiteralPlugin(nil, "="),
+		NewParseOptionalPlugin(pSpace, nil),
+		pNatural,
+		NewParseLiteralPlugin(nil, ";"),
+	}, nil)
+	pLine := NewParseAllPlugin([]SubparserOp{
+		NewParseRecoverPlugin(pStmt, NewParseAndPlugin(NewParseLiteralPlugin(nil, "\n"), nil), nil),
+		NewParseLiteralPlugin(nil, "\n"),
+	}, nil)
+	p := NewParseAllPlugin([]SubparserOp{
+		NewParseMulti0Plugin(pLine, nil),
+		NewParseEOFPlugin(nil),
+	}, nil)
+
+	pd := NewParseData("test", "a = 1;\nb = ;\nc = 3;\nd 4;\n")
+	pd, _ = p(pd, nil)
+	if pd.Result.HasError() {
+		t.Fatalf("expected all errors to be recovered, got: %s", printErrors(pd.Result.Feedback))
+	}
+	values := pd.Result.Value.([]interface{})[0].([]interface{})
+	if len(values) != 4 {
+		t.Fatalf("expected 4 lines, got: %#v", values)
+	}
+	for i, want := range []string{"", "b = ;", "", "d 4;"} {
+		node, ok := values[i].([]interface{})[0].(*ErrorNode)
+		if want == "" && ok || want != "" && (!ok || node.Text != want) {
+			t.Errorf("line %d: expected error node %q, got: %#v", i+1, want, values[i])
+		}
+	}
+
+	_, err = pd.GetFeedbackErrors()
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected parse errors, got: %v", err)
+	}
+	lines := map[int]bool{}
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		if errors.As(e, &pe) {
+			lines[pe.Line()] = true
+		}
+	}
+	if len(lines) != 2 || !lines[2] || !lines[4] {
+		t.Errorf("expected errors in lines 2 and 4, got errors in lines: %v\n%v", lines, err)
+	}
+}
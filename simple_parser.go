@@ -3,6 +3,7 @@ package gparselib
 import (
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
@@ -17,16 +18,22 @@ func ParseLiteral(
 	pluginSemantics SemanticsOp,
 	cfgLiteral string,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindLiteral, Config: []string{cfgLiteral}})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "literal '"+cfgLiteral+"'"))
+	}
 	cfgN := len(cfgLiteral)
 	pos := pd.Source.pos
-	if len(pd.Source.content) >= pos+cfgN &&
-		pd.Source.content[pos:pos+cfgN] == cfgLiteral {
-
+	pd.Source.touch(pos + cfgN)
+	if strings.HasPrefix(pd.Source.restN(cfgN), cfgLiteral) {
 		createMatchedResult(pd, cfgN)
 	} else {
-		createUnmatchedResult(
+		createExpectedResult(
 			pd,
 			0,
+			"'"+cfgLiteral+"'",
 			"Literal '"+cfgLiteral+"' expected",
 			nil)
 	}
@@ -50,9 +57,15 @@ func ParseIdent(
 	pluginSemantics SemanticsOp,
 	cfgFirstChar, cfgFollowingChars string,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindIdent, Config: []string{cfgFirstChar, cfgFollowingChars}})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "identifier"))
+	}
 	var n int
 	pos := pd.Source.pos
-	substr := pd.Source.content[pos:]
+	substr := pd.Source.rest()
 
 	for {
 		r, size := utf8.DecodeRuneInString(substr)
@@ -71,10 +84,11 @@ func ParseIdent(
 		}
 	}
 
+	pd.Source.touch(pos + n + utf8.UTFMax)
 	if n > 0 {
 		createMatchedResult(pd, n)
 	} else {
-		createUnmatchedResult(pd, 0, "Identifier expected", nil)
+		createExpectedResult(pd, 0, "identifier", "Identifier expected", nil)
 	}
 	pd, ctx = handleSemantics(pluginSemantics, pd, ctx)
 	return pd, ctx
@@ -98,22 +112,27 @@ func ParseNatural(
 	pluginSemantics SemanticsOp,
 	cfgRadix int,
 ) (*ParseData, interface{}, error) {
+	if pd.describer != nil {
+		pd, ctx = describeParser(pd, ctx, &Descriptor{Kind: KindNatural, Config: []string{strconv.Itoa(cfgRadix)}})
+		return pd, ctx, nil
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "natural number"))
+	}
 	if cfgRadix < 2 || cfgRadix > 36 {
 		return nil, nil,
-			&parseError{
-				where: "",
-				myErr: fmt.Sprintf(
+			&ParseError{
+				Msg: fmt.Sprintf(
 					"The radix has to be between 2 and 36, but is: %d",
 					cfgRadix,
 				),
-				baseErr: nil,
 			}
 	}
 	cfgDigits := allDigits[:cfgRadix]
 
 	var n int
 	pos := pd.Source.pos
-	substr := pd.Source.content[pos:]
+	substr := pd.Source.rest()
 
 	for i, digit := range substr {
 		if strings.IndexRune(cfgDigits, unicode.ToLower(digit)) >= 0 {
@@ -122,16 +141,17 @@ func ParseNatural(
 			break
 		}
 	}
+	pd.Source.touch(pos + n + utf8.UTFMax)
 	if n > 0 {
 		val, err := strconv.ParseUint(substr[:n], cfgRadix, 64)
 		if err == nil {
 			createMatchedResult(pd, n)
 			pd.Result.Value = val
 		} else {
-			createUnmatchedResult(pd, 0, "Natural number expected", err)
+			createExpectedResult(pd, 0, "natural number", "Natural number expected", err)
 		}
 	} else {
-		createUnmatchedResult(pd, 0, "Natural number expected", nil)
+		createExpectedResult(pd, 0, "natural number", "Natural number expected", nil)
 	}
 	pd, ctx = handleSemantics(pluginSemantics, pd, ctx)
 	return pd, ctx, nil
@@ -156,14 +176,21 @@ func ParseEOF(
 	pd *ParseData, ctx interface{},
 	pluginSemantics SemanticsOp,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindEOF})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "end of input"))
+	}
 	pos := pd.Source.pos
-	n := len(pd.Source.content)
+	n := len(pd.Source.restN(1))
+	pd.Source.touch(pos + 1)
 
-	if n > pos {
-		createUnmatchedResult(pd, 0,
+	if n > 0 {
+		createExpectedResult(pd, 0, "end of input",
 			fmt.Sprintf(
 				"Expecting end of input but still got %d bytes",
-				n-pos,
+				n,
 			),
 			nil,
 		)
@@ -188,9 +215,15 @@ func ParseSpace(
 	pluginSemantics SemanticsOp,
 	cfgEOLOK bool,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindSpace, Config: []string{strconv.FormatBool(cfgEOLOK)}})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "white space"))
+	}
 	var n int
 	pos := pd.Source.pos
-	substr := pd.Source.content[pos:]
+	substr := pd.Source.rest()
 
 	for {
 		r, size := utf8.DecodeRuneInString(substr)
@@ -204,10 +237,11 @@ func ParseSpace(
 			break
 		}
 	}
+	pd.Source.touch(pos + n + utf8.UTFMax)
 	if n > 0 {
 		createMatchedResult(pd, n)
 	} else {
-		createUnmatchedResult(pd, 0, "Expecting white space", nil)
+		createExpectedResult(pd, 0, "white space", "Expecting white space", nil)
 	}
 	return handleSemantics(pluginSemantics, pd, ctx)
 }
@@ -241,18 +275,34 @@ func (pr *RegexpParser) ParseRegexp(
 	pd *ParseData, ctx interface{},
 	pluginSemantics SemanticsOp,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindRegexp, Config: []string{(*regexp.Regexp)(pr).String()[1:]}})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "regexp `"+(*regexp.Regexp)(pr).String()[1:]+"`"))
+	}
 	re := (*regexp.Regexp)(pr)
 	pos := pd.Source.pos
-	substr := pd.Source.content[pos:]
-	match := re.FindStringIndex(substr)
+	substr := pd.Source.rest()
+	var match []int
+	if pd.Memo != nil && pd.Memo.incremental {
+		// the exact reach is needed for reusing results after edits
+		rr := &reachReader{s: substr}
+		match = re.FindReaderIndex(rr)
+		pd.Source.touch(pos + rr.reach)
+	} else {
+		match = re.FindStringIndex(substr)
+		pd.Source.touch(pos + len(substr) + 1)
+	}
 
 	if match != nil {
 		createMatchedResult(pd, match[1])
 		pd.Result.Value = pd.Result.Text
 	} else {
-		createUnmatchedResult(
+		createExpectedResult(
 			pd,
 			0,
+			"`"+re.String()[1:]+"`",
 			"Expecting match for regexp `"+re.String()[1:]+"`",
 			nil,
 		)
@@ -260,6 +310,22 @@ func (pr *RegexpParser) ParseRegexp(
 	return handleSemantics(pluginSemantics, pd, ctx)
 }
 
+// reachReader reads runes from a string and remembers how far it got.
+type reachReader struct {
+	s     string
+	reach int
+}
+
+func (rr *reachReader) ReadRune() (r rune, size int, err error) {
+	if rr.reach >= len(rr.s) {
+		rr.reach = len(rr.s) + 1 // the end has been examined, too
+		return 0, 0, io.EOF
+	}
+	r, size = utf8.DecodeRuneInString(rr.s[rr.reach:])
+	rr.reach += size
+	return r, size, nil
+}
+
 // NewParseRegexpPlugin creates a plugin sporting a regular expression parser.
 func NewParseRegexpPlugin(
 	pluginSemantics SemanticsOp,
@@ -283,6 +349,13 @@ func ParseLineComment(
 	pluginSemantics SemanticsOp,
 	cfgStart string,
 ) (*ParseData, interface{}, error) {
+	if pd.describer != nil {
+		pd, ctx = describeParser(pd, ctx, &Descriptor{Kind: KindLineComment, Config: []string{cfgStart}})
+		return pd, ctx, nil
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "line comment '"+cfgStart+"'"))
+	}
 	if cfgStart == "" {
 		return nil, nil,
 			errors.New(
@@ -292,20 +365,21 @@ func ParseLineComment(
 
 	pos := pd.Source.pos
 	l := len(cfgStart)
-	n := min(pos+l, len(pd.Source.content))
-	substr := pd.Source.content[pos:n]
+	rest := pd.Source.rest()
 
-	if substr == cfgStart {
-		i := strings.IndexRune(pd.Source.content[n:], '\n')
+	if strings.HasPrefix(rest, cfgStart) {
+		i := strings.IndexRune(rest[l:], '\n')
 		if i >= 0 {
 			l += i
 		} else {
-			l = len(pd.Source.content) - pos
+			l = len(rest)
 		}
+		pd.Source.touch(pos + l + 1)
 		createMatchedResult(pd, l)
 		pd.Result.Value = ""
 	} else {
-		createUnmatchedResult(pd, 0, "Expecting line comment", nil)
+		pd.Source.touch(pos + l)
+		createExpectedResult(pd, 0, "'"+cfgStart+"'", "Expecting line comment", nil)
 	}
 	pd, ctx = handleSemantics(pluginSemantics, pd, ctx)
 	return pd, ctx, nil
@@ -338,6 +412,13 @@ func ParseBlockComment(
 	pluginSemantics SemanticsOp,
 	cfgStart, cfgEnd string,
 ) (*ParseData, interface{}, error) {
+	if pd.describer != nil {
+		pd, ctx = describeParser(pd, ctx, &Descriptor{Kind: KindBlockComment, Config: []string{cfgStart, cfgEnd}})
+		return pd, ctx, nil
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "block comment '"+cfgStart+"'"))
+	}
 	if cfgStart == "" {
 		return nil, nil,
 			errors.New(
@@ -354,15 +435,15 @@ func ParseBlockComment(
 	lEnd := len(cfgEnd)
 
 	pos := pd.Source.pos
-	n := min(pos+lBeg, len(pd.Source.content))
-	substr := pd.Source.content[pos:n]
+	rest := pd.Source.rest()
+	substr := rest[:min(lBeg, len(rest))]
 
 	if substr == cfgStart {
 		afterBackslash := false
 		stringType := ' '
 		found := false
 		endRune, _ := utf8.DecodeRuneInString(cfgEnd)
-		reststr := pd.Source.content[n:]
+		reststr := rest[lBeg:]
 
 	RuneLoop:
 		for i, r := range reststr {
@@ -396,9 +477,12 @@ func ParseBlockComment(
 			}
 		}
 		if found {
+			pd.Source.touch(pd.Source.pos + lBeg + pos)
 			createMatchedResult(pd, lBeg+pos)
 			pd.Result.Value = ""
 		} else {
+			pd.Source.touch(pos + len(rest) + 1)
+			expect(pd, pos+len(rest), "'"+cfgEnd+"'")
 			createUnmatchedResult(
 				pd,
 				lBeg,
@@ -408,9 +492,11 @@ func ParseBlockComment(
 			pd.Source.pos += lBeg
 		}
 	} else {
-		createUnmatchedResult(
+		pd.Source.touch(pos + lBeg)
+		createExpectedResult(
 			pd,
 			0,
+			"'"+cfgStart+"'",
 			fmt.Sprintf(
 				"Expecting block comment starting with '%s', got '%s'",
 				cfgStart,
@@ -446,9 +532,15 @@ func ParseGoodRunes(
 	pluginSemantics SemanticsOp,
 	cfgAccept func(rune) bool,
 ) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindGoodRunes})
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "good runes"))
+	}
 	var n int
 	pos := pd.Source.pos
-	substr := pd.Source.content[pos:]
+	substr := pd.Source.rest()
 
 	for {
 		r, size := utf8.DecodeRuneInString(substr)
@@ -463,10 +555,11 @@ func ParseGoodRunes(
 		}
 	}
 
+	pd.Source.touch(pos + n + utf8.UTFMax)
 	if n > 0 {
 		createMatchedResult(pd, n)
 	} else {
-		createUnmatchedResult(pd, 0, "Acceptable runes expected", nil)
+		createExpectedResult(pd, 0, "acceptable rune", "Acceptable runes expected", nil)
 	}
 	pd, ctx = handleSemantics(pluginSemantics, pd, ctx)
 	return pd, ctx
@@ -0,0 +1,171 @@
+package gparselib
+
+import (
+	"slices"
+	"strings"
+)
+
+// SourceSet hosts several named sources that are parsed together
+// (e.g.: a main file and all files included by it).
+// Every source gets its own range of global positions, so all feedback
+// can be resolved back to the right source.
+// Included sources are loaded with the configured function.
+type SourceSet struct {
+	load    func(name string) (string, error)
+	sources []*SourceData // all sources sorted by base
+	size    int           // size of all sources (in global positions)
+	active  []string      // names of the sources that are parsed right now
+}
+
+// NewSourceSet creates a new, empty SourceSet.
+// The function is used to load the content of included sources.
+func NewSourceSet(load func(name string) (string, error)) *SourceSet {
+	return &SourceSet{load: load}
+}
+
+// NewParseData creates a new ParseData for the main source of the set.
+func (ss *SourceSet) NewParseData(name string, content string) *ParseData {
+	pd := NewParseData(name, content)
+	ss.add(&pd.Source)
+	ss.active = append(ss.active[:0], name)
+	return pd
+}
+
+// Source returns the source containing the global position and the
+// position in the source.
+// If no source has been added yet, nil is returned.
+func (ss *SourceSet) Source(globalPos int) (*SourceData, int) {
+	i, found := slices.BinarySearchFunc(ss.sources, globalPos, func(sd *SourceData, pos int) int {
+		return sd.base - pos
+	})
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return nil, 0
+	}
+	return ss.sources[i], globalPos - ss.sources[i].base
+}
+
+// Where describes the given global position in a human-readable way.
+func (ss *SourceSet) Where(globalPos int) string {
+	sd, pos := ss.Source(globalPos)
+	if sd == nil {
+		return ""
+	}
+	return where(sd, pos)
+}
+
+func (ss *SourceSet) add(sd *SourceData) {
+	sd.set = ss
+	sd.base = ss.size
+	ss.size += len(sd.content) + 1 // the end of the source gets its own position, too
+	ss.sources = append(ss.sources, sd)
+}
+
+// IncludeSource parses the named source of the source set with the
+// subparser.
+// It is meant to be called by semantics (e.g.: of an include directive).
+// The value of the included source becomes the value of the current result
+// and its feedback is added with global positions.
+// The tracer, the memo table and the tracking of the furthest failure (see
+// FurthestError) are shared with the included source.
+// A source that is included again is loaded only once and keeps its global
+// positions.
+// Errors are reported if the source can't be loaded, the source data doesn't
+// belong to a source set or the source includes itself (directly or
+// indirectly).
+func IncludeSource(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp,
+	name string,
+) (*ParseData, interface{}) {
+	ss := pd.Source.set
+	switch {
+	case ss == nil:
+		addIncludeError(pd, "Source '"+name+"' can't be included without a source set", nil)
+		return pd, ctx
+	case slices.Contains(ss.active, name):
+		addIncludeError(pd,
+			"Include cycle: "+strings.Join(append(slices.Clone(ss.active), name), " -> "),
+			nil,
+		)
+		return pd, ctx
+	}
+	var ipd *ParseData
+	if i := slices.IndexFunc(ss.sources, func(sd *SourceData) bool { return sd.Name == name }); i >= 0 {
+		// included again (e.g.: after backtracking): keep its global positions
+		ipd = &ParseData{Source: *ss.sources[i]}
+		ipd.Source.pos = 0
+		ipd.Source.reach = 0
+	} else {
+		content, err := ss.load(name)
+		if err != nil {
+			addIncludeError(pd, "Source '"+name+"' can't be included", err)
+			return pd, ctx
+		}
+		ipd = NewParseData(name, content)
+		ipd.Source.SetColumnMode(pd.Source.colMode, pd.Source.tabWidth)
+		ss.add(&ipd.Source)
+	}
+	ipd.Memo = pd.Memo // the keys are global positions
+	ipd.Tracer = pd.Tracer
+	ipd.predicates = pd.predicates
+	ipd.backtracks = pd.backtracks
+	ss.active = append(ss.active, name)
+	ipd, ctx = pluginSubparser(ipd, ctx)
+	ss.active = ss.active[:len(ss.active)-1]
+	includeFailure(pd, ipd)
+
+	// Positions are relative to the base of the source,
+	// so they are global positions for the main source.
+	for _, fb := range ipd.Result.Feedback {
+		pd.Result.Feedback = append(pd.Result.Feedback, &FeedbackItem{
+			Pos:  ipd.Source.base + fb.Pos - pd.Source.base,
+			Kind: fb.Kind,
+			Msg:  fb.Msg,
+		})
+	}
+	if ipd.Result.HasError() {
+		pd.Result.ErrPos = pd.Result.Pos
+		pd.Result.Value = nil
+	} else {
+		pd.Result.Value = ipd.Result.Value
+	}
+	return pd, ctx
+}
+
+// includeFailure makes the furthest failure of the failed included source the
+// furthest failure at the current position.
+func includeFailure(pd, ipd *ParseData) {
+	if !ipd.Result.HasError() || ipd.failure == nil || pd.failure != nil && pd.failure.pos > pd.Source.pos {
+		return
+	}
+	f := *ipd.failure
+	if f.src == nil {
+		f.src, f.srcPos = &ipd.Source, f.pos
+	}
+	f.pos = pd.Source.pos
+	pd.failure = &f
+}
+
+// NewIncludeSemantics creates semantics that include the source named by the
+// value of the result (it has to be a string).
+// The included source is parsed with the subparser (see IncludeSource).
+func NewIncludeSemantics(pluginSubparser SubparserOp) SemanticsOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		name, ok := pd.Result.Value.(string)
+		if !ok {
+			addIncludeError(pd, "The name of the included source has to be a string", nil)
+			return pd, ctx
+		}
+		return IncludeSource(pd, ctx, pluginSubparser, name)
+	}
+}
+
+// addIncludeError turns the current result into an error at its start.
+func addIncludeError(pd *ParseData, msg string, baseErr error) {
+	pd.Result.ErrPos = pd.Result.Pos
+	pd.Result.Value = nil
+	pd.AddError(pd.Result.Pos, msg, baseErr)
+}
@@ -0,0 +1,191 @@
+package gparselib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newIncludeTestParser() SubparserOp {
+	var file SubparserOp
+	fileRef := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return file(pd, ctx)
+	}
+	pName, _ := NewParseRegexpPlugin(nil, `[a-z0-9.]+`)
+	pSpace := NewParseSpacePlugin(nil, true)
+	pInclude := NewParseAllPlugin(
+		[]SubparserOp{NewParseLiteralPlugin(nil, "include "), pName, pSpace},
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			return IncludeSource(pd, ctx, fileRef, pd.SubResults[1].Text)
+		},
+	)
+	pWord := NewParseAllPlugin([]SubparserOp{
+		NewParseNotPlugin(NewParseLiteralPlugin(nil, "include"), nil),
+		NewParseIdentPlugin(nil, "", ""),
+		pSpace,
+	}, nil)
+	file = NewParseAllPlugin([]SubparserOp{
+		NewParseMulti0Plugin(NewParseAnyPlugin([]SubparserOp{pInclude, pWord}, nil), nil),
+		NewParseEOFPlugin(nil),
+	}, nil)
+	return file
+}
+
+func TestIncludeSource(t *testing.T) {
+	files := map[string]string{
+		"ok.txt":     "foo\nbar\n",
+		"bad.txt":    "foo\nbar 1\n",
+		"cycle1.txt": "foo\ninclude cycle2.txt\n",
+		"cycle2.txt": "include cycle1.txt\n",
+	}
+	load := func(name string) (string, error) {
+		content, ok := files[name]
+		if !ok {
+			return "", errors.New("file not found")
+		}
+		return content, nil
+	}
+	p := newIncludeTestParser()
+
+	specs := []struct {
+		name             string
+		givenContent     string
+		expectedFeedback []string
+		expectedWhere    string
+	}{
+		{
+			name:         "ok",
+			givenContent: "main\ninclude ok.txt\nend\n",
+		}, {
+			name:             "error in included source",
+			givenContent:     "main\ninclude bad.txt\nend\n",
+			expectedFeedback: []string{"File 'bad.txt', line 2, column 5:\nbar 1\n"},
+			expectedWhere:    "File 'bad.txt', line 2, column 5:\nbar 1\n",
+		}, {
+			name:             "missing source",
+			givenContent:     "main\ninclude missing.txt\n",
+			expectedFeedback: []string{"File 'main', line 2, column 1:", "Source 'missing.txt' can't be included: file not found"},
+			expectedWhere:    "File 'main', line 2, column 1:",
+		}, {
+			name:             "include cycle",
+			givenContent:     "main\ninclude cycle1.txt\n",
+			expectedFeedback: []string{"File 'cycle2.txt', line 1, column 1:", "Include cycle: main -> cycle1.txt -> cycle2.txt -> cycle1.txt"},
+			expectedWhere:    "File 'cycle2.txt', line 1, column 1:",
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			ss := NewSourceSet(load)
+			pd := ss.NewParseData("main", spec.givenContent)
+			pd, _ = p(pd, nil)
+			info, err := pd.GetFeedback()
+			if len(spec.expectedFeedback) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, s := range spec.expectedFeedback {
+				if !strings.Contains(info, s) {
+					t.Errorf("expected feedback containing %q, got: %s", s, info)
+				}
+			}
+			found := false
+			for _, fb := range pd.Result.Feedback {
+				if strings.Contains(ss.Where(fb.Pos), spec.expectedWhere) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected an error with a global position resolving to %q", spec.expectedWhere)
+			}
+		})
+	}
+}
+
+func TestIncludeSource_Again(t *testing.T) {
+	loads := 0
+	ss := NewSourceSet(func(name string) (string, error) {
+		loads++
+		return "foo\nbar 1\n", nil
+	})
+	pInclude := newIncludeTestParser()
+	lit := func(s string) SubparserOp { return NewParseLiteralPlugin(nil, s) }
+	p := NewParseAnyPlugin([]SubparserOp{ // the include is parsed again after backtracking
+		NewParseAllPlugin([]SubparserOp{pInclude, lit("!")}, nil),
+		NewParseAllPlugin([]SubparserOp{pInclude, lit("?")}, nil),
+	}, nil)
+	pd := ss.NewParseData("main", "include bad.txt\n")
+	size := ss.size
+	pd, _ = p(pd, nil)
+	if !pd.Result.HasError() {
+		t.Fatalf("expected an error")
+	}
+	if loads != 1 || len(ss.sources) != 2 || ss.size != size+len("foo\nbar 1\n")+1 {
+		t.Errorf("expected the included source to be added once, got %d loads and %d sources of size %d",
+			loads, len(ss.sources), ss.size)
+	}
+	if pe := pd.FurthestError(); pe == nil || pe.Source != "bad.txt" || pe.Line() != 2 || pe.Column() != 5 {
+		t.Errorf("expected the furthest error in 'bad.txt', line 2, column 5, got: %v", pe)
+	}
+}
+
+func TestIncludeSource_WithoutSourceSet(t *testing.T) {
+	pd := NewParseData("main", "include ok.txt\n")
+	pd, _ = newIncludeTestParser()(pd, nil)
+	info, _ := pd.GetFeedback()
+	if !strings.Contains(info, "can't be included without a source set") {
+		t.Errorf("expected feedback about the missing source set, got: %s", info)
+	}
+}
+
+func TestIncludeSource_ParseData(t *testing.T) {
+	ss := NewSourceSet(func(name string) (string, error) {
+		return "foo\nbar 1\n", nil
+	})
+	pd := ss.NewParseData("main", "main\ninclude bad.txt\nend\n")
+	pd.Memo = NewMemoTable(0)
+	trace := &strings.Builder{}
+	pd.Tracer = NewTextTracer(trace)
+	pd, _ = newIncludeTestParser()(pd, nil)
+	if !pd.Result.HasError() {
+		t.Fatalf("expected an error")
+	}
+
+	found := false
+	for _, fb := range pd.Result.Feedback {
+		if strings.HasPrefix(pd.Source.Where(fb.Pos), "File 'bad.txt', line 2, column 5:\nbar 1\n") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected feedback resolving to 'bad.txt', line 2, column 5, got: %s", printErrors(pd.Result.Feedback))
+	}
+	diags := pd.Diagnostics()
+	if len(diags["bad.txt"]) == 0 || diags["bad.txt"][0].Range.Start != (Position{Line: 1, Character: 4}) {
+		t.Errorf("expected diagnostics for 'bad.txt' starting at line 1, character 4, got: %+v", diags)
+	}
+	if pe := pd.FurthestError(); pe == nil || pe.Source != "bad.txt" || pe.Line() != 2 || pe.Column() != 5 {
+		t.Errorf("expected the furthest error in 'bad.txt', line 2, column 5, got: %v", pe)
+	}
+	if !strings.Contains(trace.String(), "identifier at 26 matched \"foo\"") { // global position
+		t.Errorf("expected the included source to be traced, got:\n%s", trace.String())
+	}
+
+	// the memo table is shared with global positions
+	pIncluded := NewParseMemoPlugin("word", NewParseIdentPlugin(nil, "", ""))
+	p := NewParseAllPlugin([]SubparserOp{NewParseLiteralPlugin(nil, "include "), pIncluded},
+		func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			return IncludeSource(pd, ctx, pIncluded, "word.txt")
+		},
+	)
+	pd = ss.NewParseData("main", "include main")
+	pd.Memo = NewMemoTable(0)
+	pd, _ = p(pd, nil)
+	if pd.Memo.Len() != 2 || pd.Memo.entries[memoKey{"word", pd.Source.set.size - len("foo\nbar 1\n") - 1}] == nil {
+		t.Errorf("expected memoized results for both sources, got: %v", pd.Memo.entries)
+	}
+}
@@ -0,0 +1,191 @@
+package gparselib
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// defaultStreamWindow is used for streamed sources if no valid window size
+// is given.
+const defaultStreamWindow = 64 * 1024
+
+// sourceStream reads the content of a streamed source on demand.
+type sourceStream struct {
+	r      io.Reader
+	buf    strings.Builder // the content of the window (shared with SourceData)
+	window int             // minimum lookahead of the parsers
+	eof    bool            // true if the reader is exhausted
+	err    error           // first error of the reader (except io.EOF)
+}
+
+// NewStreamSourceData creates a new SourceData that reads its content from
+// the reader on demand.
+// Only a window of the content is kept in memory.
+// The window grows when parsers look ahead and shrinks when consumed content
+// is released (see ReleaseSource, ParseStream and ParseCut).
+// ParseMulti doesn't release content because its result keeps the results of
+// all matches; repetitions at the top level should use ParseStream instead.
+// The parsers of this package always look ahead at least the window size
+// (if no valid window size is given, 64 KiB are used).
+// So single tokens (e.g.: comments) mustn't be longer than the window size.
+// Source lines in feedback are cut off at the end of the window.
+func NewStreamSourceData(name string, r io.Reader, window int) SourceData {
+	if window <= 0 {
+		window = defaultStreamWindow
+	}
+	return SourceData{
+		Name:   name,
+		lines:  []int{0},
+		stream: &sourceStream{r: r, window: window},
+	}
+}
+
+// NewStreamParseData creates a new, completely initialized ParseData for a
+// streamed source (see NewStreamSourceData).
+func NewStreamParseData(name string, r io.Reader, window int) *ParseData {
+	return &ParseData{Source: NewStreamSourceData(name, r, window)}
+}
+
+// Err returns the first error of the reader of a streamed source
+// (io.EOF isn't an error).
+// The end of the content is reached at the first error.
+func (sd SourceData) Err() error {
+	if sd.stream == nil {
+		return nil
+	}
+	return sd.stream.err
+}
+
+// lookahead reads until the content reaches n bytes (or the window size if
+// n isn't positive) after the position or the reader is exhausted.
+func (ss *sourceStream) lookahead(sd *SourceData, pos, n int) {
+	if n <= 0 {
+		n = ss.window
+	}
+	for !ss.eof && sd.end() < pos+n {
+		ss.read(sd, max(pos+n-sd.end(), ss.window))
+	}
+}
+
+// read appends at least n bytes (if possible) to the window and updates the
+// line index.
+func (ss *sourceStream) read(sd *SourceData, n int) {
+	buf := make([]byte, n)
+	m, err := io.ReadAtLeast(ss.r, buf, n)
+	if err != nil {
+		ss.eof = true
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			ss.err = err
+		}
+	}
+	start := sd.end()
+	ss.buf.Write(buf[:m]) // appending doesn't copy the content already read
+	sd.content = ss.buf.String()
+	for i := start - sd.offset; ; {
+		j := strings.IndexByte(sd.content[i:], '\n')
+		if j < 0 {
+			return
+		}
+		i += j + 1
+		sd.lines = append(sd.lines, sd.offset+i)
+	}
+}
+
+// ReleaseSource releases the content of a streamed source before the given
+// position (actually before the start of its line).
+// It should only be called if no parser will backtrack before the position.
+// Memoized results and data of the tracer before the start of the line are
+// released, too.
+// For other sources nothing happens.
+func (pd *ParseData) ReleaseSource(pos int) {
+	sd := &pd.Source
+	if sd.stream == nil || pos <= sd.offset {
+		return
+	}
+	i, start := lineOf(sd.lines, min(pos, sd.end()))
+	if i == 0 {
+		return
+	}
+	rest := sd.content[start-sd.offset:]
+	sd.stream.buf = strings.Builder{}
+	sd.stream.buf.Grow(len(rest) + sd.stream.window)
+	sd.stream.buf.WriteString(rest)
+	sd.content = sd.stream.buf.String()
+	sd.lines = append([]int(nil), sd.lines[i:]...)
+	sd.firstLine += i
+	sd.offset = start
+	if pd.Memo != nil {
+		pd.Memo.releaseBefore(sd.base + start)
+	}
+	pd.releaseTracer(start)
+}
+
+// ParseStream calls its subparser as often as possible (like ParseMulti0).
+// But the semantics are called for every single match of the subparser and
+// the consumed content is released afterwards (see ReleaseSource) if no
+// parser can backtrack to it anymore.
+// So arbitrarily large streamed sources can be parsed with limited memory.
+// The result has neither text nor value; the semantics have to handle the
+// values (e.g.: by storing them in the context).
+// Results of parent parsers only contain the text that hasn't been released.
+func ParseStream(
+	pd *ParseData, ctx interface{},
+	pluginSubparser SubparserOp, pluginSemantics SemanticsOp,
+) (*ParseData, interface{}) {
+	if pd.describer != nil {
+		return describeParser(pd, ctx, &Descriptor{Kind: KindStream}, pluginSubparser)
+	}
+	if pd.Tracer != nil {
+		defer traceExit(pd, traceEnter(pd, "stream"))
+	}
+	orgPos := pd.Source.pos
+	var feedback []*FeedbackItem
+
+	for {
+		pos := pd.Source.pos
+		pd.Result = nil
+		pd.backtracks++
+		pd, ctx = pluginSubparser(pd, ctx)
+		pd.backtracks--
+		if pd.Result.HardError {
+			pd.Source.pos = orgPos
+			pd.Result.Pos = orgPos // make result 'our result'
+			pd.Result.Feedback = append(feedback, pd.Result.Feedback...)
+			return pd, ctx
+		}
+		if pd.Result.HasError() {
+			pd.Source.pos = pos
+			break
+		}
+		if pd.Source.pos == pos { // no progress
+			pd.Result = nil
+			break
+		}
+		pd, ctx = handleSemantics(pluginSemantics, pd, ctx)
+		pd.CleanFeedback(true)
+		feedback = append(feedback, pd.Result.Feedback...)
+		if pd.backtracks == 0 && pd.predicates == 0 {
+			pd.ReleaseSource(pd.Source.pos)
+		}
+	}
+
+	var lastFeedback []*FeedbackItem
+	if pd.Result != nil {
+		lastFeedback = pd.Result.Feedback
+	}
+	pd.Result = &ParseResult{
+		Pos:      orgPos,
+		ErrPos:   -1,
+		Feedback: addPotentialProblems(feedback, lastFeedback),
+	}
+	pd.SubResults = nil
+	return pd, ctx
+}
+
+// NewParseStreamPlugin creates a plugin sporting a stream parser.
+func NewParseStreamPlugin(pluginSubparser SubparserOp, pluginSemantics SemanticsOp) SubparserOp {
+	return func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseStream(pd, ctx, pluginSubparser, pluginSemantics)
+	}
+}
@@ -0,0 +1,148 @@
+package gparselib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func newStreamTestParser(maxWindow *int) SubparserOp {
+	pLine := NewParseAllPlugin([]SubparserOp{
+		NewParseIdentPlugin(nil, "", ""),
+		NewParseLiteralPlugin(nil, "="),
+		NewParseIdentPlugin(nil, "", ""),
+		NewParseSpacePlugin(nil, true),
+	}, func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		pd.Result.Value = pd.SubResults[2].Text
+		return pd, ctx
+	})
+	return NewParseAllPlugin([]SubparserOp{
+		NewParseStreamPlugin(pLine, func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+			*maxWindow = max(*maxWindow, len(pd.Source.content))
+			return pd, append(ctx.([]string), pd.Result.Value.(string))
+		}),
+		NewParseEOFPlugin(nil),
+	}, nil)
+}
+
+func TestParseStream(t *testing.T) {
+	b := strings.Builder{}
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&b, "key=value%d\n", i)
+	}
+	content := b.String()
+
+	maxWindow := 0
+	p := newStreamTestParser(&maxWindow)
+	pd := NewStreamParseData("stream", iotest.HalfReader(strings.NewReader(content)), 32)
+	pd, ctx := p(pd, []string{})
+
+	if pd.Result.HasError() {
+		t.Fatalf("unexpected error: %v", pd.Result.Feedback)
+	}
+	values := ctx.([]string)
+	if len(values) != 1000 || values[0] != "value0" || values[999] != "value999" {
+		t.Errorf("expected 1000 values from 'value0' to 'value999', got %d: %q", len(values), values)
+	}
+	if pd.Source.pos != len(content) {
+		t.Errorf("expected position %d, got: %d", len(content), pd.Source.pos)
+	}
+	if maxWindow > 4*32 {
+		t.Errorf("expected the window to stay small, but it grew to %d bytes", maxWindow)
+	}
+}
+
+func TestParseStream_Error(t *testing.T) {
+	content := strings.Repeat("key=value\n", 999) + "key value\n"
+
+	maxWindow := 0
+	p := newStreamTestParser(&maxWindow)
+	pd := NewStreamParseData("stream", strings.NewReader(content), 32)
+	pd, _ = p(pd, []string{})
+
+	if !pd.Result.HasError() {
+		t.Fatalf("expected an error")
+	}
+	pe := pd.FurthestError()
+	if pe == nil || pe.Line() != 1000 || pe.Column() != 4 || pe.SourceLine() != "key value" {
+		t.Errorf("expected furthest error in line 1000, column 4 ('key value'), got: %#v", pe)
+	}
+	expectedMsg := "expected '='; found ' '"
+	if pe != nil && pe.Msg != expectedMsg {
+		t.Errorf("expected message %q, got: %q", expectedMsg, pe.Msg)
+	}
+	info, _ := pd.GetFeedback()
+	if !strings.Contains(info, "File 'stream', line 1000, column 4:\nkey value\n") {
+		t.Errorf("expected feedback for line 1000, got: %s", info)
+	}
+}
+
+func TestStreamSourceData_Err(t *testing.T) {
+	readErr := errors.New("read error")
+	pd := NewStreamParseData("stream", iotest.ErrReader(readErr), 0)
+	pd, _ = NewParseEOFPlugin(nil)(pd, nil)
+	if pd.Result.HasError() {
+		t.Errorf("expected the end of input at the first error, got: %v", pd.Result.Feedback)
+	}
+	if err := pd.Source.Err(); !errors.Is(err, readErr) {
+		t.Errorf("expected the read error, got: %v", err)
+	}
+}
+
+func TestParseStream_Backtracking(t *testing.T) {
+	pLine, err := NewParseRegexpPlugin(nil, `[a-z]+\n`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lit := func(s string) SubparserOp { return NewParseLiteralPlugin(nil, s) }
+	p := NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{NewParseStreamPlugin(pLine, nil), lit("END")}, nil),
+		NewParseAllPlugin([]SubparserOp{lit("abc\n"), lit("def\n"), lit("XYZ")}, nil),
+	}, nil)
+	content := "abc\ndef\nXYZ"
+
+	for _, pd := range []*ParseData{
+		NewParseData("string", content),
+		NewStreamParseData("stream", strings.NewReader(content), 4),
+	} {
+		pd, _ = p(pd, nil)
+		if pd.Result.HasError() {
+			t.Errorf("%s: unexpected error: %v", pd.Source.Name, pd.Result.Feedback)
+		}
+		if pd.Source.pos != len(content) {
+			t.Errorf("%s: expected position %d, got: %d", pd.Source.Name, len(content), pd.Source.pos)
+		}
+	}
+}
+
+func TestParseStream_Memo(t *testing.T) {
+	lit := func(s string) SubparserOp { return NewParseLiteralPlugin(nil, s) }
+	pWord := NewParseMemoPlugin("word", lit("ab"))
+	pLine := NewParseAnyPlugin([]SubparserOp{
+		NewParseAllPlugin([]SubparserOp{pWord, lit("!")}, nil),
+		NewParseAllPlugin([]SubparserOp{pWord, lit("\n")}, nil),
+	}, nil)
+	pd := NewStreamParseData("stream", strings.NewReader(strings.Repeat("ab\n", 100)), 8)
+	pd.Memo = NewMemoTable(0)
+	pd, _ = NewParseStreamPlugin(pLine, nil)(pd, nil)
+	if pd.Result.HasError() {
+		t.Fatalf("unexpected error: %v", pd.Result.Feedback)
+	}
+	// every line and the end of input are looked up twice
+	if stats := pd.Memo.Stats(); stats.Hits != 101 || stats.Misses != 101 {
+		t.Errorf("expected 101 hits and misses, got: %+v", stats)
+	}
+	if pd.Memo.Len() > 2 {
+		t.Errorf("expected the results before the released content to be released, got %d", pd.Memo.Len())
+	}
+
+	pd = NewStreamParseData("release", strings.NewReader("ab\nab\nab\n"), 8)
+	pd.Memo = NewMemoTable(0)
+	pd, _ = NewParseAllPlugin([]SubparserOp{pLine, pLine}, nil)(pd, nil)
+	pd.ReleaseSource(3)
+	if pd.Memo.Len() != 1 || pd.Memo.lookup("word", 3) == nil {
+		t.Errorf("expected the result after the released content to be kept, got %d results", pd.Memo.Len())
+	}
+}
@@ -0,0 +1,134 @@
+package gparselib
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TraceEvent describes the call of a parser for a Tracer.
+type TraceEvent struct {
+	Parser  string // the kind of parser and its configuration (e.g.: "literal 'if'")
+	Rule    string // name of the current grammar rule
+	Pos     int    // start position of the parser (global for sources of a SourceSet)
+	Success bool   // true if the parser matched (only set on exit)
+	Text    string // the consumed text (only set on exit)
+	// Alternatives is the number of alternatives of ParseAny and ParseBest.
+	Alternatives int
+	// Index is the index of the alternative of ParseAny or ParseBest that is
+	// called (only set for "alternative" events that wrap the alternative).
+	Index int
+}
+
+// Tracer is called on enter and exit of every parser of this package if it
+// is set in ParseData.Tracer.
+// It is meant for debugging and profiling grammars.
+// Without a Tracer nothing is traced.
+type Tracer interface {
+	Enter(ev TraceEvent)
+	Exit(ev TraceEvent)
+}
+
+// releaser is implemented by tracers that keep data per position.
+// The data before the position isn't needed anymore because no parser will
+// backtrack before it.
+type releaser interface {
+	releaseBefore(pos int)
+}
+
+// releaseTracer releases the data of the tracer before the position.
+func (pd *ParseData) releaseTracer(pos int) {
+	if r, ok := pd.Tracer.(releaser); ok {
+		r.releaseBefore(pd.Source.base + pos)
+	}
+}
+
+// traceEnter calls the tracer for entering the parser.
+// The event is returned for traceExit.
+func traceEnter(pd *ParseData, parser string) TraceEvent {
+	return traceEnterAlternatives(pd, parser, 0)
+}
+func traceEnterAlternatives(pd *ParseData, parser string, n int) TraceEvent {
+	ev := TraceEvent{Parser: parser, Rule: pd.ruleName, Pos: pd.Source.base + pd.Source.pos, Alternatives: n}
+	pd.Tracer.Enter(ev)
+	return ev
+}
+
+// traceAlternative calls the alternative with the index of ParseAny or
+// ParseBest inside of an "alternative" event.
+// So tracers know the index without counting the calls of the subparsers.
+func traceAlternative(pd *ParseData, ctx interface{}, subparser SubparserOp, index int) (*ParseData, interface{}) {
+	ev := TraceEvent{
+		Parser: "alternative " + strconv.Itoa(index),
+		Rule:   pd.ruleName,
+		Pos:    pd.Source.base + pd.Source.pos,
+		Index:  index,
+	}
+	pd.Tracer.Enter(ev)
+	pd, ctx = subparser(pd, ctx)
+	traceExit(pd, ev)
+	return pd, ctx
+}
+
+// traceExit calls the tracer for exiting the parser of the event.
+// It is meant to be deferred.
+func traceExit(pd *ParseData, ev TraceEvent) {
+	if pd.Result != nil && !pd.Result.HasError() {
+		ev.Success = true
+		ev.Text = pd.Result.Text
+	}
+	pd.Tracer.Exit(ev)
+}
+
+// TextTracer writes an indented line of text for every traced event.
+// The events can be filtered by rule and position.
+type TextTracer struct {
+	Rules []string // only events of these rules are written (all if empty)
+	From  int      // only events of parsers starting at From or later are written
+	To    int      // only events of parsers starting before To are written (if positive)
+	w     io.Writer
+	depth int
+}
+
+// NewTextTracer creates a new TextTracer writing to the writer.
+// Errors of the writer are ignored.
+func NewTextTracer(w io.Writer) *TextTracer {
+	return &TextTracer{w: w}
+}
+
+// Enter writes the event and increases the indentation.
+func (tt *TextTracer) Enter(ev TraceEvent) {
+	if tt.accept(ev) {
+		fmt.Fprintf(tt.w, "%s> %s at %d%s\n", tt.indent(), ev.Parser, ev.Pos, traceRule(ev.Rule))
+	}
+	tt.depth++
+}
+
+// Exit decreases the indentation and writes the event.
+func (tt *TextTracer) Exit(ev TraceEvent) {
+	tt.depth--
+	if !tt.accept(ev) {
+		return
+	}
+	if ev.Success {
+		fmt.Fprintf(tt.w, "%s< %s at %d%s matched %q\n", tt.indent(), ev.Parser, ev.Pos, traceRule(ev.Rule), ev.Text)
+	} else {
+		fmt.Fprintf(tt.w, "%s< %s at %d%s failed\n", tt.indent(), ev.Parser, ev.Pos, traceRule(ev.Rule))
+	}
+}
+
+func (tt *TextTracer) accept(ev TraceEvent) bool {
+	return (len(tt.Rules) == 0 || slices.Contains(tt.Rules, ev.Rule)) &&
+		ev.Pos >= tt.From && (tt.To <= 0 || ev.Pos < tt.To)
+}
+func (tt *TextTracer) indent() string {
+	return strings.Repeat("  ", tt.depth)
+}
+func traceRule(rule string) string {
+	if rule == "" {
+		return ""
+	}
+	return " in rule '" + rule + "'"
+}
@@ -0,0 +1,89 @@
+package gparselib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextTracer(t *testing.T) {
+	pKeyword := func(pd *ParseData, ctx interface{}) (*ParseData, interface{}) {
+		return ParseRule(pd, ctx, NewParseLiteralPlugin(nil, "if"), "keyword")
+	}
+	pX := NewParseLiteralPlugin(nil, "x")
+	p := NewParseAllPlugin([]SubparserOp{NewParseAnyPlugin([]SubparserOp{pKeyword, pX}, nil), pX}, nil)
+
+	specs := []struct {
+		name     string
+		tracer   func(tt *TextTracer)
+		expected string
+	}{
+		{
+			name:   "all",
+			tracer: func(tt *TextTracer) {},
+			expected: `> all at 0
+  > any at 0
+    > alternative 0 at 0
+      > rule at 0 in rule 'keyword'
+        > literal 'if' at 0 in rule 'keyword'
+        < literal 'if' at 0 in rule 'keyword' failed
+      < rule at 0 in rule 'keyword' failed
+    < alternative 0 at 0 failed
+    > alternative 1 at 0
+      > literal 'x' at 0
+      < literal 'x' at 0 matched "x"
+    < alternative 1 at 0 matched "x"
+  < any at 0 matched "x"
+  > literal 'x' at 1
+  < literal 'x' at 1 matched "x"
+< all at 0 matched "xx"
+`,
+		}, {
+			name:   "rule",
+			tracer: func(tt *TextTracer) { tt.Rules = []string{"keyword"} },
+			expected: `      > rule at 0 in rule 'keyword'
+        > literal 'if' at 0 in rule 'keyword'
+        < literal 'if' at 0 in rule 'keyword' failed
+      < rule at 0 in rule 'keyword' failed
+`,
+		}, {
+			name:   "position",
+			tracer: func(tt *TextTracer) { tt.From, tt.To = 1, 2 },
+			expected: `  > literal 'x' at 1
+  < literal 'x' at 1 matched "x"
+`,
+		},
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			b := &strings.Builder{}
+			tt := NewTextTracer(b)
+			spec.tracer(tt)
+			pd := NewParseData(spec.name, "xx")
+			pd.Tracer = tt
+			pd, _ = p(pd, nil)
+			if pd.Result.HasError() {
+				t.Fatalf("unexpected errors: %s", printErrors(pd.Result.Feedback))
+			}
+			if b.String() != spec.expected {
+				t.Errorf("expected trace:\n%s\ngot:\n%s", spec.expected, b.String())
+			}
+		})
+	}
+}
+
+// noopTracer ignores all events.
+type noopTracer struct{}
+
+func (noopTracer) Enter(TraceEvent) {}
+func (noopTracer) Exit(TraceEvent)  {}
+
+// BenchmarkTracer compares parsing without a tracer to parsing with a tracer
+// that does nothing, so the cost of the check for the tracer can be seen.
+func BenchmarkTracer(b *testing.B) {
+	b.Run("off", func(b *testing.B) {
+		benchmarkTokens(b, nil)
+	})
+	b.Run("noop", func(b *testing.B) {
+		benchmarkTokens(b, noopTracer{})
+	})
+}